@@ -0,0 +1,105 @@
+package env
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/http/pprof"
+	"reflect"
+	"time"
+)
+
+/*
+
+	grace := env.NewGraceful()
+	grace.Admin("127.0.0.1:6060", &cfg)
+	...
+	curl 127.0.0.1:6060/healthz
+	curl 127.0.0.1:6060/config
+	go tool pprof http://127.0.0.1:6060/debug/pprof/heap
+
+*/
+
+// Admin starts an internal admin http server on addr serving the
+// net/http/pprof handlers under /debug/pprof/, a /healthz endpoint
+// that reports 200 until shutdown is signaled and 503 thereafter and a
+// /config endpoint with the resolved cfg structs as WriteConf key = value
+// lines with the hidden and secret values masked; the server is shutdown
+// gracefully with the other managed processes
+func (g *graceful) Admin(addr string, cfg ...interface{}) *graceful {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		if g.ctx.Err() != nil {
+			http.Error(w, "shutdown", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/config", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for i := range cfg {
+			if v := reflect.Indirect(reflect.ValueOf(cfg[i])); v.Kind() == reflect.Struct {
+				confWrite(w, "", v, true)
+			}
+		}
+	})
+
+	g.serve(addr, mux)
+
+	if !g.silent {
+		log.Printf("admin: %s", addr)
+	}
+
+	return g
+}
+
+// Ready reports true once the bootstrap is complete and until a shutdown is
+// signaled; for orchestrator readiness gating
+func (g *graceful) Ready() bool { return g.ready.Load() && g.ctx.Err() == nil }
+
+// ServeHealth starts a managed http server on addr that reports the Ready
+// state; 200 when ready, otherwise 503
+func (g *graceful) ServeHealth(addr string) *graceful {
+
+	g.serve(addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !g.Ready() {
+			http.Error(w, "not ready", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok\n"))
+	}))
+
+	if !g.silent {
+		log.Printf("health: %s", addr)
+	}
+
+	return g
+}
+
+// serve h on addr as a managed http server that is shutdown gracefully
+// with the other managed processes
+func (g *graceful) serve(addr string, h http.Handler) {
+
+	srv := &http.Server{Addr: addr, Handler: h}
+
+	g.wgShutdown.Add(1)
+	go func() {
+		defer g.wgShutdown.Done()
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Printf("%s: %s", addr, err)
+		}
+	}()
+
+	go func() {
+		<-g.ctx.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+}
@@ -0,0 +1,130 @@
+package main
+
+/*
+
+envtag rewrites struct field comments of the form // default: X into
+the default:"X" struct tag used by the env parser so defaults can live
+as readable comments; the file is rewritten in place
+
+	type params struct {
+		Number int `help:"a number"` // default: 5
+	}
+
+	//go:generate go run github.com/zxdev/env/cmd/envtag $GOFILE
+
+*/
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var comment = regexp.MustCompile(`^//\s*default:\s*(.*?)\s*$`)
+
+func main() {
+
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "envtag: usage envtag {file.go} ...")
+		os.Exit(1)
+	}
+
+	for _, path := range os.Args[1:] {
+		if err := rewrite(path); err != nil {
+			fmt.Fprintf(os.Stderr, "envtag: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+}
+
+// rewrite the struct tags in the file at path
+func rewrite(path string) error {
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	if err != nil {
+		return err
+	}
+
+	var changed bool
+	ast.Inspect(file, func(n ast.Node) bool {
+		st, ok := n.(*ast.StructType)
+		if !ok {
+			return true
+		}
+		for _, field := range st.Fields.List {
+			value, ok := lookup(field.Doc)
+			if !ok {
+				if value, ok = lookup(field.Comment); !ok {
+					continue
+				}
+			}
+			if tag(field, value) {
+				changed = true
+			}
+		}
+		return true
+	})
+
+	if !changed {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	if err = format.Node(&buf, fset, file); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// lookup the // default: X comment value
+func lookup(cg *ast.CommentGroup) (string, bool) {
+
+	if cg != nil {
+		for _, c := range cg.List {
+			if m := comment.FindStringSubmatch(c.Text); m != nil {
+				return m[1], true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// tag sets the default:"value" struct tag and reports when it was changed
+func tag(field *ast.Field, value string) bool {
+
+	var raw string
+	if field.Tag != nil {
+		raw, _ = strconv.Unquote(field.Tag.Value)
+	}
+
+	current, ok := reflect.StructTag(raw).Lookup("default")
+	switch {
+	case ok && current == value:
+		return false
+	case ok:
+		raw = strings.Replace(raw, "default:"+strconv.Quote(current), "default:"+strconv.Quote(value), 1)
+	case len(raw) > 0:
+		raw += " default:" + strconv.Quote(value)
+	default:
+		raw = "default:" + strconv.Quote(value)
+	}
+
+	if field.Tag == nil {
+		field.Tag = &ast.BasicLit{Kind: token.STRING}
+	}
+	field.Tag.Value = "`" + raw + "`"
+
+	return true
+}
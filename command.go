@@ -0,0 +1,108 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+/*
+
+	var serve struct {
+		Port int `default:"8080" help:"listen port"`
+	}
+	env.Command("serve", &serve, func() { ... })
+	env.Command("migrate", nil, func() { ... })
+	if err := env.Dispatch(); err != nil && !errors.Is(err, env.ErrHelp) {
+		os.Exit(1)
+	}
+	...
+	app serve -port 9090
+	app serve help
+
+*/
+
+// subcommands registry
+var commands = struct {
+	sync.RWMutex
+	m map[string]command
+}{m: make(map[string]command)}
+
+// command is a registered subcommand
+type command struct {
+	cfg interface{}
+	run func()
+}
+
+// ErrCommand is returned by Dispatch when os.Args[1] is not a registered
+// command; the available commands are listed
+var ErrCommand = errors.New("unknown command")
+
+// Command registers the name subcommand; cfg is parsed from the args that
+// follow the name, a nil cfg has no switches, and run is called by Dispatch
+func Command(name string, cfg interface{}, run func()) {
+	commands.Lock()
+	commands.m[name] = command{cfg: cfg, run: run}
+	commands.Unlock()
+}
+
+// Dispatch matches os.Args[1] against the registered commands, parses the
+// command cfg as NewEnvE does and calls its run function; the parser error
+// or ErrHelp is returned without running, and an unknown or missing command
+// lists the available commands and returns ErrCommand; empty Options.Args
+// fall back to os.Args
+func Dispatch(opt ...Options) error {
+
+	var o Options
+	if len(opt) > 0 {
+		o = opt[0]
+	}
+	args := o.Args
+	if len(args) == 0 {
+		args = os.Args
+	}
+	if len(args) == 0 {
+		return ErrCommand // no program name
+	}
+
+	var name string
+	if len(args) > 1 {
+		name = args[1]
+	}
+
+	commands.RLock()
+	cmd, ok := commands.m[name]
+	var list []string
+	for k := range commands.m {
+		list = append(list, k)
+	}
+	commands.RUnlock()
+
+	if !ok {
+		sort.Strings(list)
+		fmt.Fprintf(os.Stderr, "\n %s commands\n", filepath.Base(args[0]))
+		for _, k := range list {
+			fmt.Fprintf(os.Stderr, "  %s\n", k)
+		}
+		fmt.Fprintln(os.Stderr)
+		if name == "help" || name == "-help" || name == "--help" {
+			return ErrHelp
+		}
+		return ErrCommand
+	}
+
+	if cmd.cfg != nil {
+		o.Args = append(args[:1:1], args[2:]...) // command switches
+		if _, err := NewEnvE(&o, cmd.cfg); err != nil {
+			return err
+		}
+	}
+	if cmd.run != nil {
+		cmd.run()
+	}
+
+	return nil
+}
@@ -1,24 +1,34 @@
 package env
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Conf populates a json object applying tag:default conf values
-// that are overloaded by the file source when configured at the
-// primary level; no recurrsion support
+// that are overloaded by the file source when configured; defaults
+// are applied to nested structs at every level
 //
 //	type Example struct {
 //		Text   string `json:"text,omitempty"`
 //		Number int    `json:"number,omitempty" default:"10"`
 //		Show bool     `json:"show,omitempty" default:"on"`
+//		Server struct {
+//			Port int `json:"port,omitempty" default:"8080"`
+//		} `json:"server"`
 //	}
 //
-// supports: string, int, bool
+// supports: string, int, bool, float32/64, []string
 func Conf(cfg interface{}, path string) {
 
 	// conf.json {"text":"hello","number":5}
@@ -31,24 +41,7 @@ func Conf(cfg interface{}, path string) {
 
 	v := reflect.Indirect(reflect.ValueOf(cfg))
 	if v.Type().Kind() == reflect.Struct {
-		for j := 0; j < v.NumField(); j++ {
-			if s, ok := v.Type().Field(j).Tag.Lookup("default"); ok {
-				switch v.Field(j).Kind() {
-				case reflect.String:
-					v.Field(j).SetString(s)
-				case reflect.Int:
-					n, _ := strconv.ParseInt(s, 10, 0)
-					v.Field(j).SetInt(n)
-				case reflect.Bool:
-					switch strings.ToLower(s) {
-					// case "off", "no", "false", "0":
-					// 	v.Field(j).SetBool(false)
-					case "on", "yes", "ok", "true", "1":
-						v.Field(j).SetBool(true)
-					}
-				}
-			}
-		}
+		confDefaults(v)
 	}
 
 	// load json object configuration file
@@ -61,3 +54,421 @@ func Conf(cfg interface{}, path string) {
 	}
 
 }
+
+// ConfYAML populates a struct applying tag:default conf values that are
+// overloaded by the yaml file source; nested structs are populated from
+// the nested yaml maps, the key is the yaml tag name or the lowercased
+// field name
+//
+//	type Example struct {
+//		Text   string `yaml:"text"`
+//		Server struct {
+//			Port int `default:"8080"`
+//		}
+//	}
+//
+//	env.ConfYAML(&cfg, "app.yaml")
+//
+// supports: string, int, bool, float32/64, []string
+func ConfYAML(cfg interface{}, path string) {
+
+	v := reflect.Indirect(reflect.ValueOf(cfg))
+	if v.Type().Kind() != reflect.Struct {
+		return
+	}
+	confDefaults(v)
+
+	// load yaml configuration file
+	if len(path) > 0 {
+		f, err := os.Open(path)
+		if err == nil {
+			var m = make(map[string]string)
+			yamlRead(f, m)
+			f.Close()
+			confYAML(v, "", m)
+		}
+	}
+
+}
+
+// confDefaults applies the tag:default values to v and its nested structs
+func confDefaults(v reflect.Value) {
+
+	for j := 0; j < v.NumField(); j++ {
+		if !v.Field(j).CanSet() {
+			continue
+		}
+		if v.Field(j).Kind() == reflect.Struct {
+			confDefaults(v.Field(j))
+			continue
+		}
+		if s, ok := v.Type().Field(j).Tag.Lookup("default"); ok {
+			confSet(v.Field(j), s)
+		}
+	}
+
+}
+
+// confSet sets the string, int, bool, float and []string field value from
+// s; a []string is split on comma
+func confSet(v reflect.Value, s string) {
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int:
+		n, _ := strconv.ParseInt(s, 10, 0)
+		v.SetInt(n)
+	case reflect.Bool:
+		v.SetBool(boolean(s))
+	case reflect.Float32, reflect.Float64:
+		f, _ := strconv.ParseFloat(s, v.Type().Bits())
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.String {
+			e := strings.Split(s, ",")
+			v.Set(reflect.MakeSlice(v.Type(), len(e), len(e)))
+			for i := range e {
+				v.Index(i).SetString(strings.TrimSpace(e[i]))
+			}
+		}
+	}
+
+}
+
+// confYAML sets the fields of v from the dotted yaml keys in m
+func confYAML(v reflect.Value, prefix string, m map[string]string) {
+
+	for j := 0; j < v.NumField(); j++ {
+
+		if !v.Field(j).CanSet() {
+			continue
+		}
+
+		key := strings.ToLower(v.Type().Field(j).Name)
+		if tag, ok := v.Type().Field(j).Tag.Lookup("yaml"); ok {
+			if tag = strings.Split(tag, ",")[0]; tag == "-" {
+				continue
+			} else if len(tag) > 0 {
+				key = tag
+			}
+		}
+
+		if v.Field(j).Kind() == reflect.Struct {
+			confYAML(v.Field(j), prefix+key+".", m)
+			continue
+		}
+
+		if s, ok := m[prefix+key]; ok {
+			confSet(v.Field(j), s)
+		}
+	}
+
+}
+
+// confRead loads the conf k:v sets from path into m; the format is
+// selected by the file extension (.yaml, .yml, .toml, .json) and is otherwise the
+// ini style k:v format; a missing file is not an error, while malformed lines
+// or a file without any k:v sets are reported and the usable sets are loaded
+func confRead(path string, m map[string]string) error {
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	var t = make(map[string]string)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yamlRead(f, t)
+	case ".toml":
+		err = tomlRead(f, t)
+	case ".json":
+		err = jsonRead(f, t)
+	default:
+		err = iniRead(f, t)
+	}
+	if err == nil && len(t) == 0 {
+		err = errors.New("no k:v sets")
+	}
+
+	// the [environ] section is set in the process environment
+	// for libraries that read it directly; eg. AWS_REGION
+	for k := range t {
+		if strings.HasPrefix(k, "environ.") {
+			os.Setenv(k[8:], t[k])
+			continue
+		}
+		m[k] = t[k]
+	}
+
+	return err
+}
+
+// jsonRead loads a json object into m as k:v sets where nested objects use
+// dotted keys and arrays are joined with commas
+func jsonRead(r io.Reader, m map[string]string) error {
+
+	var obj map[string]interface{}
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	if err := d.Decode(&obj); err != nil {
+		return err
+	}
+	jsonFlatten("", obj, m)
+
+	return nil
+}
+
+// jsonFlatten the decoded json object into m
+func jsonFlatten(prefix string, obj map[string]interface{}, m map[string]string) {
+
+	for k, v := range obj {
+		switch value := v.(type) {
+		case map[string]interface{}:
+			jsonFlatten(prefix+k+".", value, m)
+		case []interface{}:
+			var item []string
+			for i := range value {
+				item = append(item, fmt.Sprint(value[i]))
+			}
+			m[prefix+k] = strings.Join(item, ",")
+		case nil:
+			m[prefix+k] = ""
+		default:
+			m[prefix+k] = fmt.Sprint(value)
+		}
+	}
+
+}
+
+// iniRead loads the ini style k:v sets; the key is separated from the
+// value by the first =, :, or whitespace, # lines are comments and keys
+// following a [section] line are prefixed as section.key; a line ending
+// with \ is joined with the next line
+//
+//	host = example.com
+//	port: 8080
+//	show on
+//	[environ]
+//	AWS_REGION = us-east-1
+func iniRead(r io.Reader, m map[string]string) error {
+
+	var section, join string
+	var line int
+	var err error
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+
+		// a trailing \ continues the line on the next line
+		if strings.HasSuffix(text, "\\") {
+			join += strings.TrimSpace(strings.TrimSuffix(text, "\\")) + " "
+			continue
+		}
+		text, join = strings.TrimSpace(join+text), ""
+
+		if len(text) == 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			section = strings.TrimSpace(text[1:len(text)-1]) + "."
+			if section == "." {
+				section = ""
+			}
+			continue
+		}
+		if n := strings.IndexAny(text, "=: \t"); n > 0 {
+			m[section+text[:n]] = strings.TrimLeft(text[n:], "=: \t")
+		} else if err == nil {
+			err = fmt.Errorf("line %d: malformed %q", line, text)
+		}
+	}
+
+	if err == nil {
+		err = scanner.Err()
+	}
+
+	return err
+}
+
+// WriteConf writes the populated cfg structs to path as key = value lines,
+// using the env alias or the lowercased field name as the key, so the
+// effective configuration can be audited or reloaded; env:"-", hidden and
+// secret fields are skipped and nested structs use dotted keys; the file is
+// replaced atomically keeping its mode and the prior file is kept as
+// {path}.bak; Parse reads the nested dotted keys back
+func (p *Options) WriteConf(path string, cfg ...interface{}) error {
+
+	var v = make([]reflect.Value, len(cfg))
+	for i := range cfg {
+		v[i] = reflect.Indirect(reflect.ValueOf(cfg[i]))
+		if v[i].Kind() != reflect.Struct {
+			return fmt.Errorf("%s %w", v[i].Type().Name(), errMisconfigured)
+		}
+	}
+
+	if err := backup(path); err != nil {
+		return err
+	}
+
+	return save(path, func(f io.Writer) error {
+		w := bufio.NewWriter(f)
+		for i := range v {
+			confWrite(w, "", v[i], false)
+		}
+		return w.Flush()
+	})
+}
+
+// backup keeps one generation of the prior path as {path}.bak with the same
+// mode; a hard link when supported so the backup is never partly written
+func backup(path string) error {
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil // nothing to keep
+	}
+
+	os.Remove(path + ".bak")
+	if os.Link(path, path+".bak") == nil {
+		return nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err == nil {
+		err = os.WriteFile(path+".bak", b, info.Mode().Perm())
+	}
+	if err == nil {
+		err = os.Chmod(path+".bak", info.Mode().Perm())
+	}
+
+	return err
+}
+
+// CommandLine returns the -flag=value switches that reproduce the populated
+// cfg structs; only values that differ from the tag:default are included and
+// hidden and secret values are masked
+//
+//	-number=7 -secret=<hidden> -hosts=a.com,b.com
+func (p *Options) CommandLine(cfg ...interface{}) string {
+
+	var line []string
+	for i := range cfg {
+
+		v := reflect.Indirect(reflect.ValueOf(cfg[i]))
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+
+		for j := 0; j < v.NumField(); j++ {
+
+			sf := v.Type().Field(j)
+			tag := sf.Tag.Get("env")
+			if !v.Field(j).CanSet() || tag == "-" {
+				continue
+			}
+
+			// the tag:default as populated by the parser
+			dflt := reflect.New(sf.Type).Elem()
+			if val, ok := sf.Tag.Lookup("devdefault"); ok && p.Development {
+				p.setField(dflt, expand(val), sf.Tag.Get("sep"))
+			} else if val, ok := sf.Tag.Lookup("default"); ok {
+				val = expand(val)
+				if n, err := parseRate(val); err == nil && strings.Contains(","+tag+",", ",rate,") {
+					val = strconv.FormatInt(n, 10)
+				}
+				p.setField(dflt, val, sf.Tag.Get("sep"))
+			}
+
+			value := confValue(v.Field(j), sf.Tag.Get("sep"))
+			if value == confValue(dflt, sf.Tag.Get("sep")) {
+				continue
+			}
+
+			switch {
+			case strings.Contains(","+tag+",", ",hidden,"), strings.Contains(","+tag+",", ",secret,"):
+				value = "<hidden>"
+			case strings.ContainsAny(value, " \t\"'$\\"):
+				value = strconv.Quote(value)
+			}
+			line = append(line, fmt.Sprintf("-%s=%s", strings.ToLower(sf.Name), value))
+		}
+	}
+
+	return strings.Join(line, " ")
+}
+
+// confWrite writes the struct fields of v as prefix.key = value lines; the
+// hidden and secret fields are skipped or, when mask, written as <hidden>
+func confWrite(w io.Writer, prefix string, v reflect.Value, mask bool) {
+
+	for j := 0; j < v.NumField(); j++ {
+
+		sf := v.Type().Field(j)
+		if !v.Field(j).CanSet() {
+			continue // unexported
+		}
+
+		key := strings.ToLower(sf.Name)
+		tag := sf.Tag.Get("env")
+		if tag == "-" {
+			continue
+		}
+
+		var hidden bool
+		for _, opt := range strings.Split(tag, ",") {
+			switch opt {
+			case "hidden", "secret":
+				hidden = true
+			case "order", "require", "environ", "rate", "ci", "count", "":
+			default:
+				key = opt[strings.LastIndex(opt, "|")+1:] // alias; the long name of v|verbose
+			}
+		}
+		if hidden {
+			if mask {
+				fmt.Fprintf(w, "%s%s = <hidden>\n", prefix, key)
+			}
+			continue
+		}
+
+		field := v.Field(j)
+		if field.Kind() == reflect.Struct && field.Type() != reflect.TypeOf(time.Time{}) {
+			confWrite(w, prefix+key+".", field, mask)
+			continue
+		}
+
+		fmt.Fprintf(w, "%s%s = %s\n", prefix, key, confValue(field, sf.Tag.Get("sep")))
+	}
+
+}
+
+// confValue formats the field value in the form read back by setField
+func confValue(v reflect.Value, sep string) string {
+
+	if len(sep) == 0 {
+		sep = ","
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		var item []string
+		for i := 0; i < v.Len(); i++ {
+			item = append(item, confValue(v.Index(i), sep))
+		}
+		return strings.Join(item, sep)
+
+	case reflect.Map:
+		var item []string
+		for _, k := range v.MapKeys() {
+			item = append(item, fmt.Sprintf("%s=%s", confValue(k, sep), confValue(v.MapIndex(k), sep)))
+		}
+		sort.Strings(item)
+		return strings.Join(item, sep)
+	}
+
+	return fmt.Sprint(v.Interface())
+}
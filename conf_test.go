@@ -0,0 +1,49 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfLayered(t *testing.T) {
+
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "site.conf"), filepath.Join(dir, "host.conf")
+	os.WriteFile(a, []byte("port = 80\nname = site\n"), 0644)
+	os.WriteFile(b, []byte("port = 8080\n"), 0644)
+
+	var cfg struct {
+		Port int
+		Name string
+	}
+	o := Options{Silent: true, Conf: a, ConfPath: []string{b}, Args: []string{"app"}}
+	if err := o.Parse(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 8080 || cfg.Name != "site" {
+		t.Fatalf("later file did not win %+v", cfg)
+	}
+}
+
+func TestConfNestedDefaults(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "conf.json")
+	os.WriteFile(path, []byte(`{"Server":{"Host":"example.com"}}`), 0644)
+
+	var cfg struct {
+		Server struct {
+			Host string `default:"localhost"`
+			Port int    `default:"8080"`
+			TLS  struct {
+				On   bool   `default:"on"`
+				Cert string `default:"cert.pem"`
+			}
+		}
+	}
+	Conf(&cfg, path)
+	if cfg.Server.Host != "example.com" || cfg.Server.Port != 8080 ||
+		!cfg.Server.TLS.On || cfg.Server.TLS.Cert != "cert.pem" {
+		t.Fatalf("%+v", cfg)
+	}
+}
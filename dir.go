@@ -1,6 +1,7 @@
 package env
 
 import (
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,14 +12,54 @@ import (
 // when the last element contains any of the following ._- characters
 // and fs.FileMode is coded to 0755
 func Dir(a ...string) string {
+	path, _ := DirErr(a...)
+	return path
+}
+
+// DirErr is Dir reporting the directory tree creation error
+func DirErr(a ...string) (string, error) { return dirMode(0755, a...) }
+
+// DirMode is Dir creating the directory tree with mode; eg. 0700 for
+// directories holding secrets or sockets
+func DirMode(mode fs.FileMode, a ...string) string {
+	path, _ := dirMode(mode, a...)
+	return path
+}
+
+// DirFile is Dir without the guess; the last element is always a file and
+// only the parent directory tree is created
+func DirFile(a ...string) string {
+	path, _ := mkdir(0755, true, a...)
+	return path
+}
 
+// DirPath is Dir without the guess; every element is a directory and the
+// full tree is created, eg. my-data or 2024.01
+func DirPath(a ...string) string {
+	path, _ := mkdir(0755, false, a...)
+	return path
+}
+
+// dirMode creates the directory tree of a with mode; a file is presumed
+// when the last element contains any of the ._- characters
+func dirMode(mode fs.FileMode, a ...string) (string, error) {
+	return mkdir(mode, len(a) > 0 && strings.ContainsAny(a[len(a)-1], "._-"), a...)
+}
+
+// mkdir creates the directory tree of a with mode, less the last
+// element when it is a file
+func mkdir(mode fs.FileMode, file bool, a ...string) (string, error) {
+
+	var err error
 	if len(a) > 0 {
-		if strings.ContainsAny(a[len(a)-1], "._-") {
-			os.MkdirAll(filepath.Join(a[:len(a)-1]...), 0755)
-		} else {
-			os.MkdirAll(filepath.Join(a...), 0755)
+		dir := filepath.Join(a...)
+		if file {
+			dir = filepath.Join(a[:len(a)-1]...)
+		}
+		if len(dir) > 0 { // a bare file name
+			err = os.MkdirAll(dir, mode)
 		}
 	}
 
-	return filepath.Join(a...)
+	return filepath.Join(a...), err
 }
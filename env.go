@@ -1,14 +1,17 @@
 package env
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // These var should be set externally by the build command
@@ -27,7 +30,7 @@ type Path struct {
 // populates any interfaces that are provided
 //
 //	tags
-//		env:"alias,order,require,environ,hidden"
+//		env:"alias,order,require,environ,hidden,secret"
 //		help:"description"
 //		default:"value" (bool, string, int)
 //
@@ -41,12 +44,41 @@ func NewEnv(cfg ...interface{}) (path *Path) {
 //	Silent: log configuration output
 //	NoHelp: silences the help output
 //	SetENV: set KEY=VALUE in environemnt
+//	Conf: conf file path; -config {path} overrides
+//	Presence: bool environment presence sets true; eg. DEBUG=
+//	Development: use tag:devdefault over tag:default (autodetected)
+//	Secrets: restricted conf file for env:"secret" fields
+//	JSON: read the {name}.json sibling after the conf file
+//	Strict: a conf file that exists but is malformed or empty is an error
+//	ConfPath: layered conf files merged in order; eg. site then host overrides
+//	Args: parse these args instead of os.Args; eg. a REPL or subcommand
+//	JSONEnv: environment var holding a JSON object layered over the conf files
 type Options struct {
-	Silent bool // silence log configuration output
-	NoHelp bool // silence help output
-	SetENV bool // set KEY=VALUE in environment
+	Silent      bool     // silence log configuration output
+	NoHelp      bool     // silence help output
+	SetENV      bool     // set KEY=VALUE in environment
+	Conf        string   // conf file path (default: {etc}/{name}/{name}.conf)
+	Presence    bool     // bool ENV presence is true when empty
+	Development bool     // development mode; set when not linux
+	Secrets     string   // secrets conf file path for env:"secret" fields; mode 0600
+	JSON        bool     // also read the {conf}.json sibling of the conf file
+	Strict      bool     // fail on a malformed or empty conf file
+	ConfPath    []string // layered conf files read in order after Conf; later files win
+	Args        []string // args to parse in place of os.Args when not nil
+	JSONEnv     string   // env var with a JSON object; eg. APP_CONFIG='{"port":8080}'
+
+	secrets map[string]string
+	set     map[string]bool // fields explicitly set by conf, args or env
+	check   bool            // validate; collect the errors
+	errs    []error
+	initial map[interface{}]reflect.Value // cfg struct values before the first Parse
 }
 
+// IsSet reports whether the named field (lowercase) was explicitly set by a
+// conf, args or environment source rather than left at its tag:default; a
+// bool set false is distinguished from a bool that was never provided
+func (p *Options) IsSet(name string) bool { return p.set[strings.ToLower(name)] }
+
 // Configure sets up the basic environment and returns environment paths;
 // pass Options as the first item to set or specify custom configuration
 // options to silence log and help output and env.Options.M map populates,
@@ -54,11 +86,123 @@ type Options struct {
 // tag, that is then overloaded by command line swithches, in this order
 func Configure(cfg ...interface{}) (path *Path) {
 
+	path, err := configure(cfg...)
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrHelp):
+		os.Exit(0)
+	case errors.Is(err, errMisconfigured):
+		fmt.Fprintf(os.Stderr, "%s: %s\n", filepath.Base(os.Args[0]), err)
+		os.Exit(exitCode[ExitMisconfigured])
+	case errors.Is(err, ErrInvalid):
+		fmt.Fprintf(os.Stderr, "%s: %s\n", filepath.Base(os.Args[0]), err)
+		os.Exit(exitCode[ExitValidate])
+	default:
+		fmt.Fprintf(os.Stderr, "%s: %s\n", filepath.Base(os.Args[0]), err)
+		os.Exit(exitCode[ExitConfig])
+	}
+
+	return
+}
+
+// Paths returns the environment paths without parsing or logging; the
+// production layout on linux, otherwise the _dev development layout
+func Paths() *Path {
+
+	switch runtime.GOOS {
+	case "linux": // production
+		return &Path{
+			Etc: "/etc",
+			Srv: "/srv",
+			Var: "/var",
+			Tmp: "/tmp",
+		}
+
+	default: // development
+		return &Path{
+			Etc: "_dev/etc",
+			Srv: "_dev/srv",
+			Var: "_dev/var",
+			Tmp: "_dev/tmp",
+		}
+	}
+}
+
+// hint returns the help value placeholder for the field type; eg. <int>
+func hint(v reflect.Value, sf reflect.StructField) string {
+
+	if strings.Contains(","+sf.Tag.Get("env")+",", ",rate,") {
+		return "<rate>"
+	}
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		return "<duration>"
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		name := strings.ToLower(sf.Name)
+		for _, s := range []string{"path", "dir", "file"} {
+			if strings.Contains(name, s) {
+				return "<path>"
+			}
+		}
+		return "<string>"
+	case reflect.Int, reflect.Int64:
+		return "<int>"
+	case reflect.Uint, reflect.Uint64:
+		return "<uint>"
+	case reflect.Slice:
+		return "<list>"
+	case reflect.Map:
+		return "<k=v>"
+	}
+
+	return "" // bool switch
+}
+
+// versionFile reads the VERSION file next to the executable or in the
+// working directory; a fallback when the Version ldflag is not set
+func versionFile() string {
+
+	var dirs []string
+	if exe, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Dir(exe))
+	}
+	dirs = append(dirs, ".")
+
+	for _, dir := range dirs {
+		if b, err := os.ReadFile(filepath.Join(dir, "VERSION")); err == nil {
+			return strings.TrimSpace(string(b))
+		}
+	}
+
+	return ""
+}
+
+// ErrHelp is returned by NewEnvE when the version or help output was
+// requested and rendered, or a validate found no problems
+var ErrHelp = errors.New("help requested")
+
+// ErrInvalid is returned by NewEnvE when the validate subcommand found
+// invalid parameters; Configure exits with the ExitValidate code
+var ErrInvalid = errors.New("invalid parameters")
+
+// NewEnvE is the error returning counterpart of NewEnv for embedding; it
+// does not exit and returns ErrHelp after the version or help output is
+// rendered, or the parser error, so the caller can decide whether to exit
+func NewEnvE(cfg ...interface{}) (*Path, error) {
+	return configure(cfg...)
+}
+
+// configure is the Configure flow returning errors instead of exiting
+func configure(cfg ...interface{}) (path *Path, err error) {
+
 	var opt Options
+	var caller *Options
 	if len(cfg) > 0 {
 		switch c := cfg[0].(type) {
 		case *Options:
-			opt = *c
+			opt, caller = *c, c
 			cfg = cfg[1:]
 		case Options: // bonehead
 			opt = c
@@ -67,30 +211,33 @@ func Configure(cfg ...interface{}) (path *Path) {
 	}
 
 	var name string
+	path = Paths()
 	switch runtime.GOOS {
 	case "linux": // production
-		path = &Path{
-			Etc: "/etc",
-			Srv: "/srv",
-			Var: "/var",
-			Tmp: "/tmp",
-		}
 		name = filepath.Base(os.Args[0])
 		// this can be overwritten in production environments
 		// using the build in commandline log:on functionality
 		log.SetFlags(0) // Ldate=1 Ltime=2
 
 	default: // development
-		path = &Path{
-			Etc: "_dev/etc",
-			Srv: "_dev/srv",
-			Var: "_dev/var",
-			Tmp: "_dev/tmp",
-		}
 		name = "development"
+		opt.Development = true
+	}
+
+	if len(opt.Conf) == 0 {
+		opt.Conf = filepath.Join(path.Etc, name, name+".conf")
+	}
+
+	if len(Version) == 0 {
+		Version = versionFile()
+	}
+
+	var args = opt.Args
+	if args == nil {
+		args = os.Args
 	}
 
-	if len(os.Args) > 1 {
+	if len(args) > 1 {
 
 		var n = 18
 		if len(name) > n {
@@ -103,12 +250,29 @@ func Configure(cfg ...interface{}) (path *Path) {
 			n = len(Build) + 10
 		}
 
-		switch strings.TrimLeft(os.Args[1], "-") {
+		switch strings.TrimLeft(args[1], "-") {
 		case "version":
 
 			fmt.Printf("\n %-s\n%s\n version %s\n build   %s\n\n",
 				name, strings.Repeat("-", n+2), Version, Build)
-			os.Exit(0)
+			return path, ErrHelp
+
+		case "validate":
+
+			// parse and check every parameter without starting; the
+			// validate token is not a positional order value
+			opt.Args, opt.check = append(args[:1:1], args[2:]...), true
+			if err = opt.Parse(cfg...); err != nil {
+				return path, err
+			}
+			for _, err := range opt.errs {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", name, err)
+			}
+			if len(opt.errs) > 0 {
+				return path, fmt.Errorf("%d %w", len(opt.errs), ErrInvalid)
+			}
+			fmt.Printf("%s: valid\n", name)
+			return path, ErrHelp
 
 		case "help":
 
@@ -119,12 +283,16 @@ func Configure(cfg ...interface{}) (path *Path) {
 			}
 
 			if !opt.NoHelp && len(cfg) > 0 {
+				var group = make(map[string][]string) // tag:category lines
 				for i := range cfg {
 
 					var tag string
 					var ok bool
 
 					v := reflect.Indirect(reflect.ValueOf(cfg[i]))
+					if v.Kind() != reflect.Struct {
+						continue // misconfigured; reported by Parse
+					}
 					for j := 0; j < v.NumField(); j++ {
 
 						// name field
@@ -150,36 +318,79 @@ func Configure(cfg ...interface{}) (path *Path) {
 									env.Require = "r"
 								case "environ":
 									env.Environ = "e"
-								case "hidden":
+								case "hidden", "secret":
 									env.Hidden = "*"
+								case "rate", "ci", "count":
+									// value modifiers
 								default:
 									env.Alias = v
 								}
 							}
 						}
 						// fmt.Printf(" %-15s", tag)
-						fmt.Printf(" %-15s %-5s [%-1s%-1s%-1s%-1s] ",
-							tag, env.Alias, env.Order, env.Require, env.Environ, env.Hidden)
+						line := fmt.Sprintf(" %-15s %-10s %-5s [%-1s%-1s%-1s%-1s] ",
+							tag, hint(v.Field(j), v.Type().Field(j)), env.Alias, env.Order, env.Require, env.Environ, env.Hidden)
 
 						// default field
 						tag, _ = v.Type().Field(j).Tag.Lookup("default")
-						fmt.Printf("default:%-10s ", tag)
+						line += fmt.Sprintf("default:%-10s ", tag)
 
 						// help field
 						tag, _ = v.Type().Field(j).Tag.Lookup("help")
-						fmt.Println(tag)
+						if example, ok := v.Type().Field(j).Tag.Lookup("example"); ok {
+							tag = strings.TrimSpace(tag + " e.g. " + example)
+						}
+						line += tag
+
+						category := v.Type().Field(j).Tag.Get("category")
+						group[category] = append(group[category], strings.TrimRight(line, " "))
 
 					}
 
 				}
+
+				// categories sorted; uncategorized last
+				var category []string
+				for k := range group {
+					if len(k) > 0 {
+						category = append(category, k)
+					}
+				}
+				sort.Strings(category)
+				if len(category) > 0 && len(group[""]) > 0 {
+					if _, ok := group["other"]; !ok {
+						category = append(category, "other")
+					}
+					group["other"] = append(group["other"], group[""]...)
+				} else if len(category) == 0 {
+					category = append(category, "")
+				}
+
+				for i, k := range category {
+					if len(k) > 0 {
+						if i > 0 {
+							fmt.Println()
+						}
+						fmt.Printf(" %s\n", k)
+					}
+					for _, line := range group[k] {
+						fmt.Println(line)
+					}
+				}
 			}
 			fmt.Println()
-			os.Exit(0)
+			return path, ErrHelp
 		}
 	}
 
 	if len(cfg) > 0 {
-		opt.parse(cfg...)
+		err = opt.Parse(cfg...)
+		if caller != nil {
+			caller.set = opt.set // expose IsSet to the caller
+		}
+		if err != nil {
+			return path, err
+		}
 	}
 
 	if !opt.Silent {
@@ -207,7 +418,7 @@ func Configure(cfg ...interface{}) (path *Path) {
 					if opts == "-" {
 						continue
 					}
-					if strings.Contains(opts, "hidden") {
+					if strings.Contains(opts, "hidden") || strings.Contains(opts, "secret") {
 						log.Printf(" %-15s| <hidden>", strings.ToLower(v.Type().Field(i).Name))
 						continue
 					}
@@ -217,51 +428,173 @@ func Configure(cfg ...interface{}) (path *Path) {
 			log.Printf("|%s|", strings.Repeat("-", 40))
 		}
 
+		// reproducible command line of the non-default values
+		if line := opt.CommandLine(cfg...); len(line) > 0 {
+			log.Printf(" %s %s", filepath.Base(os.Args[0]), line)
+			log.Printf("|%s|", strings.Repeat("-", 40))
+		}
+
 	}
 
 	return
 }
 
-// parse will set the speficied cfg struct field value according to the tag:env and
+// Parse will set the speficied cfg struct field value according to the tag:env and
 // tag:default provided in the struct, and will overload in the following order:
 //
-//	tag:default, conf k:v sets, os.Args, os.Environ
+//	tag:default, conf k:v sets (conf, json, then each ConfPath), JSONEnv, os.Args, os.Environ
 //
-// final values in the key:value os.Environment table.
+// final values in the key:value os.Environment table. Parse is re-entrant and may
+// be called again with different Options.Args; it does not exit and
+// returns a *FieldError naming the offending field and tag, or the misconfigured
+// interface error; Configure reports the error and exits
 //
 //	env: alias,require,order,environ field flags
 //	supports: string, bool, int/64, uint/64 types
-func (p *Options) parse(cfg ...interface{}) {
+func (p *Options) Parse(cfg ...interface{}) error {
 
 	// overlaoding order
 	// tag:default, conf, os.Args, ENV=
 
 	var m = make(map[string]string)
+	var args = p.Args
+	if args == nil {
+		args = os.Args
+	}
+	p.secrets, p.set, p.errs = nil, nil, nil
+
+	// the reserved -config {path} or -conf {path} switch overrides the
+	// conf file path and is resolved before the conf layer is read
+	var path = p.Conf
+	for i := 1; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			continue
+		}
+		key := strings.TrimLeft(args[i], "-")
+		if n := strings.IndexAny(key, "=:"); n > 0 {
+			if key[:n] == "config" || key[:n] == "conf" {
+				path = key[n+1:]
+			}
+			continue
+		}
+		if (key == "config" || key == "conf") && i+1 < len(args) {
+			path = args[i+1]
+		}
+	}
+
+	// conf k:v sets; with environment references expanded
+	conf := []string{path}
+	if p.JSON {
+		conf = append(conf, strings.TrimSuffix(path, filepath.Ext(path))+".json")
+	}
+	conf = append(conf, p.ConfPath...) // layered; later files win
+	for _, path := range conf {
+		if err := confRead(path, m); err != nil && (p.Strict || p.check) {
+			if err = p.fail(fmt.Errorf("conf %s: %w", path, err)); err != nil {
+				return err
+			}
+		}
+	}
+	for k := range m {
+		m[k] = expand(m[k])
+	}
+
+	// a JSON object env var overloads the conf files; keys follow the
+	// json tag of a field when present
+	if val, ok := os.LookupEnv(p.JSONEnv); ok && len(p.JSONEnv) > 0 {
+		var t = make(map[string]string)
+		if err := jsonRead(strings.NewReader(val), t); err != nil {
+			if err = p.fail(fmt.Errorf("%s: %w", p.JSONEnv, err)); err != nil {
+				return err
+			}
+		}
+		keys := jsonKeys(cfg...)
+		for k, val := range t {
+			if name, ok := keys[k]; ok {
+				k = name
+			}
+			m[k] = val
+		}
+	}
+
+	// secrets k:v sets; warn when group or other can read
+	if len(p.Secrets) > 0 {
+		if info, err := os.Stat(p.Secrets); err == nil && info.Mode().Perm()&0077 != 0 {
+			fmt.Fprintf(os.Stderr, "%s: secrets %s mode %s is too permissive; use 0600\n",
+				filepath.Base(os.Args[0]), p.Secrets, info.Mode().Perm())
+		}
+		p.secrets = make(map[string]string)
+		confRead(p.Secrets, p.secrets)
+	}
 
 	// processes os.Args and build/overload a map[string]string; support for single
-	// reference switches -a aa -b
-	for i := 0; i < len(os.Args); i++ {
-		if strings.HasPrefix(os.Args[i], "-") {
-			key := strings.TrimLeft(os.Args[i], "-")
+	// reference switches -a aa -b; a bool switch only consumes the next token when
+	// it is an on/off, yes/no, true/false value and -no-{switch} sets it false; a
+	// count switch counts each -v and a repeated slice or map switch appends; the short
+	// form of a v|verbose pair takes one dash and the long form two; the tokens not
+	// consumed by a switch are the positional order values
+	var a = make(map[string]string)
+	var pos []string
+	var keys = argKeys(cfg...)
+	put := func(k, val string) {
+		if prior, ok := a[k]; ok && (keys[k].kind == reflect.Slice || keys[k].kind == reflect.Map) {
+			val = prior + keys[k].sep + val
+		}
+		a[k] = val
+	}
+	for i := 0; i < len(args); i++ {
+		if strings.HasPrefix(args[i], "-") {
+			key := strings.TrimLeft(args[i], "-")
+			dash := len(args[i]) - len(key)
+			name := key
+			if n := strings.IndexAny(name, "=:"); n > 0 {
+				name = name[:n]
+			}
+			if !keys[name].dashed(dash) {
+				key = args[i] // unknown; eg. --v or -verbose for v|verbose
+			}
 			switch {
+			case keys[key].count:
+				n, _ := strconv.Atoi(a[key])
+				a[key] = strconv.Itoa(n + 1)
+			case keys[key].kind == reflect.Bool:
+				a[key] = "true"
+				if i+1 < len(args) {
+					switch strings.ToLower(args[i+1]) {
+					case "on", "off", "yes", "no", "true", "false":
+						i++
+						a[key] = args[i]
+					}
+				}
+			case strings.HasPrefix(key, "no-") && keys[key[3:]].kind == reflect.Bool && keys[key[3:]].dashed(dash):
+				a[key[3:]] = "false"
 			case strings.Contains(key, "="):
 				s := strings.SplitN(key, "=", 2)
-				m[s[0]] += s[1]
+				put(s[0], s[1])
 			case strings.Contains(key, ":"):
 				s := strings.SplitN(key, ":", 2)
-				m[s[0]] += s[1]
+				put(s[0], s[1])
 			default:
 				i++
-				if i < len(os.Args) {
-					if !strings.HasPrefix(os.Args[i], "-") {
-						m[key] = os.Args[i]
+				if i < len(args) {
+					if !strings.HasPrefix(args[i], "-") {
+						put(key, args[i])
 					} else {
 						i--
 					}
 				}
 			}
+		} else {
+			pos = append(pos, args[i])
 		}
 	}
+	for k := range a {
+		m[k] = a[k] // overload conf
+	}
+
+	// reserved; never bound to a struct field
+	delete(m, "config")
+	delete(m, "conf")
 
 	// command line log timestamp controller
 	// to turn on/off the log timestamp headers
@@ -274,6 +607,126 @@ func (p *Options) parse(cfg ...interface{}) {
 		delete(m, "log")
 	}
 
+	return p.bind(m, pos, os.LookupEnv, cfg...)
+}
+
+// fail returns err, or collects it and returns nil when validating so
+// every problem is reported rather than only the first
+func (p *Options) fail(err error) error {
+	if p.check {
+		p.errs = append(p.errs, err)
+		return nil
+	}
+	return err
+}
+
+// argKey is the switch handling of a cfg struct field
+type argKey struct {
+	kind  reflect.Kind
+	sep   string // slice element separator
+	count bool   // env:"count"
+	dash  int    // required dashes; 0 for any
+}
+
+// dashed reports whether a switch with n dashes may match the key; in a
+// short|long alias pair -v takes one dash and --verbose takes two
+func (k argKey) dashed(n int) bool { return k.dash == 0 || k.dash == n }
+
+// argKeys reports the switch handling by the names and aliases of the
+// cfg struct fields
+func argKeys(cfg ...interface{}) map[string]argKey {
+
+	var keys = make(map[string]argKey)
+	for i := range cfg {
+		v := reflect.Indirect(reflect.ValueOf(cfg[i]))
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+		for j := 0; j < v.NumField(); j++ {
+			tag := v.Type().Field(j).Tag.Get("env")
+			if !v.Field(j).CanSet() || tag == "-" {
+				continue
+			}
+			key := argKey{kind: v.Field(j).Kind(), sep: v.Type().Field(j).Tag.Get("sep")}
+			if len(key.sep) == 0 {
+				key.sep = ","
+			}
+			var alias []string
+			for _, opt := range strings.Split(tag, ",") {
+				switch opt {
+				case "count":
+					key.count = true
+				case "order", "require", "environ", "hidden", "secret", "rate", "ci", "":
+				default:
+					alias = strings.Split(opt, "|")
+				}
+			}
+			keys[strings.ToLower(v.Type().Field(j).Name)] = key
+			for n, k := range alias {
+				if len(alias) > 1 { // short|long pair
+					key.dash = 1
+					if n > 0 {
+						key.dash = 2
+					}
+				}
+				keys[k] = key
+			}
+		}
+	}
+
+	return keys
+}
+
+// jsonKeys maps the json tag names of the cfg struct fields to the
+// field names used by the parser
+func jsonKeys(cfg ...interface{}) map[string]string {
+
+	var keys = make(map[string]string)
+	for i := range cfg {
+		v := reflect.Indirect(reflect.ValueOf(cfg[i]))
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+		for j := 0; j < v.NumField(); j++ {
+			tag := strings.Split(v.Type().Field(j).Tag.Get("json"), ",")[0]
+			if len(tag) > 0 && tag != "-" {
+				keys[tag] = strings.ToLower(v.Type().Field(j).Name)
+			}
+		}
+	}
+
+	return keys
+}
+
+// errMisconfigured reports an interface that is not a struct
+var errMisconfigured = errors.New("interface misconfigured")
+
+// FieldError reports the struct field and tag that failed to parse
+type FieldError struct {
+	Field string            // struct field name
+	Tag   reflect.StructTag // struct field tag
+	Err   error
+}
+
+func (e *FieldError) Error() string { return e.Err.Error() }
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// Bind populates the cfg structs from the supplied map only, applying the
+// same tag:default, alias and require rules as the parser without using
+// os.Args, the environment or a conf file
+func Bind(m map[string]string, cfg ...interface{}) error {
+	return new(Options).bind(m, nil, nil, cfg...)
+}
+
+// bind sets the cfg struct fields from tag:default, the m map, the lookup
+// environment and the positional order args that follow the program name in
+// args; nil args or lookup are skipped
+func (p *Options) bind(m map[string]string, args []string, lookup func(string) (string, bool), cfg ...interface{}) error {
+
+	if lookup == nil {
+		lookup = func(string) (string, bool) { return "", false }
+	}
+
 	// process interfaces
 	for i := range cfg {
 
@@ -281,9 +734,45 @@ func (p *Options) parse(cfg ...interface{}) {
 
 		v := reflect.Indirect(reflect.ValueOf(cfg[i]))
 		if v.Type().Kind() != reflect.Struct {
-			fmt.Fprintf(os.Stderr, "%s: %s interface misconfigured",
-				filepath.Base(os.Args[0]), reflect.TypeOf(cfg[i]).Elem().Name())
-			os.Exit(1)
+			return fmt.Errorf("%s %w", v.Type().Name(), errMisconfigured)
+		}
+
+		// the struct values before the first Parse are the lowest layer and
+		// are restored on a repeat Parse so prior values are not kept
+		initial := v
+		if reflect.ValueOf(cfg[i]).Kind() == reflect.Ptr {
+			if p.initial == nil {
+				p.initial = make(map[interface{}]reflect.Value)
+			}
+			if _, ok := p.initial[cfg[i]]; !ok {
+				p.initial[cfg[i]] = reflect.New(v.Type()).Elem()
+				p.initial[cfg[i]].Set(v)
+			}
+			initial = p.initial[cfg[i]]
+		}
+
+		// order fields are positional and must precede the flag fields
+		var flag string
+		for j := 0; j < v.NumField(); j++ {
+			tag := v.Type().Field(j).Tag.Get("env")
+			if !v.Field(j).CanSet() || tag == "-" {
+				continue
+			}
+			switch {
+			case !strings.Contains(","+tag+",", ",order,"):
+				if len(flag) == 0 {
+					flag = strings.ToLower(v.Type().Field(j).Name)
+				}
+			case len(flag) > 0:
+				fmt.Fprintf(os.Stderr, "%s: order (%s) parameter declared after (%s) parameter\n",
+					filepath.Base(os.Args[0]), strings.ToLower(v.Type().Field(j).Name), flag)
+			}
+		}
+
+		// a section marker; eg. _ struct{} `deprecated:"use [server] instead"`
+		var deprecated string
+		if sf, ok := v.Type().FieldByName("_"); ok {
+			deprecated = sf.Tag.Get("deprecated")
 		}
 
 		// process fields
@@ -296,10 +785,11 @@ func (p *Options) parse(cfg ...interface{}) {
 			}
 
 			var value string
-			var status bool
+			var status, explicit bool
+			var fail error
 			var env struct {
-				Order, Require, Environ bool
-				Alias                   string
+				Order, Require, Environ, Rate, CI, Secret bool
+				Alias                                     string
 			}
 
 			// process tag:env
@@ -315,7 +805,16 @@ func (p *Options) parse(cfg ...interface{}) {
 						env.Require = true
 					case "environ":
 						env.Environ = true
-					// case "hidden":
+					case "rate":
+						env.Rate = true
+					case "ci":
+						env.CI = true
+					case "secret":
+						env.Secret = true
+					case "hidden":
+						// summary only
+					case "count":
+						// args only
 					default:
 						env.Alias = v
 					}
@@ -323,37 +822,159 @@ func (p *Options) parse(cfg ...interface{}) {
 				}
 			}
 
+			// set applies a value source to the field
+			set := func(val string) {
+				val, err := resolve(val)
+				if err != nil {
+					fail = fmt.Errorf("unresolved (%s) parameter; %s", name, err)
+					return
+				}
+				if strings.HasPrefix(val, "@") && v.Field(j).Kind() == reflect.String {
+					b, err := os.ReadFile(val[1:]) // @/run/secrets/token
+					if err != nil {
+						fail = fmt.Errorf("unreadable (%s) parameter; %s", name, err)
+						return
+					}
+					val = strings.TrimRight(string(b), "\r\n")
+				}
+				if env.Rate && len(val) > 0 {
+					n, err := parseRate(val)
+					if err != nil {
+						fail = fmt.Errorf("invalid (%s) parameter; %s", name, err)
+						return
+					}
+					val = strconv.FormatInt(n, 10)
+				}
+				value, status = p.setField(v.Field(j), val, v.Type().Field(j).Tag.Get("sep"))
+				explicit = true
+			}
+
+			// restore the initial value; see above
+			v.Field(j).Set(initial.Field(j))
+
+			// a nested struct binds the dotted keys of its section and the
+			// prefixed environment; eg. [server] port or SERVER_PORT
+			if field := v.Field(j); field.Kind() == reflect.Struct && field.Type() != reflect.TypeOf(time.Time{}) {
+				key := name
+				if len(env.Alias) > 0 {
+					key = env.Alias[strings.LastIndex(env.Alias, "|")+1:] // as WriteConf
+				}
+				section := make(map[string]string)
+				for k, val := range m {
+					if strings.HasPrefix(k, key+".") {
+						section[k[len(key)+1:]] = val
+					}
+				}
+				environ := func(k string) (string, bool) { return lookup(strings.ToUpper(key) + "_" + k) }
+				if err := p.bind(section, nil, environ, field.Addr().Interface()); err != nil {
+					return err
+				}
+				continue
+			}
+
 			// apply tag:default values; when defined
-			if val, ok := v.Type().Field(j).Tag.Lookup("default"); ok {
-				value, status = p.setField(v.Field(j), val)
+			if val, ok := v.Type().Field(j).Tag.Lookup("devdefault"); ok && p.Development {
+				set(expand(val))
+			} else if val, ok := v.Type().Field(j).Tag.Lookup("default"); ok {
+				set(expand(val))
 			}
+			explicit = false // a default is not explicit
 
 			// overload with conf/args values; when present
 			if val, ok := m[name]; ok {
-				value, status = p.setField(v.Field(j), val)
+				set(val)
 			}
-			if val, ok := m[env.Alias]; ok {
-				value, status = p.setField(v.Field(j), val)
+			for _, alias := range strings.Split(env.Alias, "|") { // -v or --verbose
+				if val, ok := m[alias]; ok && len(alias) > 0 {
+					set(val)
+				}
 			}
 
 			// overload with os.Environment table values; when present
-			if val, ok := os.LookupEnv(strings.ToUpper(name)); ok {
-				value, status = p.setField(v.Field(j), val)
+			if val, ok := lookup(strings.ToUpper(name)); ok {
+				if p.Presence && len(val) == 0 && v.Field(j).Kind() == reflect.Bool {
+					val = "true" // export DEBUG=
+				}
+				set(val)
+			} else if v.Field(j).Kind() == reflect.Slice {
+				// indexed NAME_0, NAME_1 .. NAME_n; stops at the first gap
+				var list []string
+				for n := 0; ; n++ {
+					val, ok := lookup(fmt.Sprintf("%s_%d", strings.ToUpper(name), n))
+					if !ok {
+						break
+					}
+					list = append(list, val)
+				}
+				if len(list) > 0 {
+					sep := v.Type().Field(j).Tag.Get("sep")
+					if len(sep) == 0 {
+						sep = ","
+					}
+					set(strings.Join(list, sep))
+				}
 			}
 
-			// check for ordering
-			if env.Order && len(os.Args) > order && !strings.HasPrefix(os.Args[order], "-") {
+			// check for ordering; a -switch token is never consumed as a positional value
+			if env.Order && len(args) > order && !strings.HasPrefix(args[order], "-") {
 				// assumption is that we take args in order present to populate
 				// the structure without using name flags {1} {2} {3} -blah
-				value, status = p.setField(v.Field(j), os.Args[order])
+				set(args[order])
 				order++
 			}
 
+			// secrets file values have the highest trust and are not
+			// overloaded by conf, args or the environment
+			if env.Secret {
+				if val, ok := p.secrets[name]; ok {
+					set(val)
+				} else {
+					for _, alias := range strings.Split(env.Alias, "|") {
+						if val, ok := p.secrets[alias]; ok && len(alias) > 0 {
+							set(val)
+							break
+						}
+					}
+				}
+			}
+
 			// check for requiirement
-			if env.Require && !status {
-				fmt.Fprintf(os.Stderr, "%s: missing required (%s) parameter\n",
-					filepath.Base(os.Args[0]), strings.ToLower(v.Type().Field(j).Name))
-				os.Exit(0)
+			if fail == nil && env.Require && !status {
+				fail = fmt.Errorf("missing required (%s) parameter", name)
+				if msg, ok := v.Type().Field(j).Tag.Lookup("requiremsg"); ok {
+					fail = errors.New(msg)
+				}
+			}
+
+			// check for range; after requirement
+			if fail == nil && status {
+				fail = bounds(v.Field(j), v.Type().Field(j))
+			}
+			if fail == nil && status {
+				fail = oneof(v.Field(j), v.Type().Field(j), env.CI)
+			}
+			if fail == nil {
+				fail = valid(v.Field(j), v.Type().Field(j), env.CI, status)
+			}
+
+			if explicit {
+				if p.set == nil {
+					p.set = make(map[string]bool)
+				}
+				p.set[name] = true
+				if len(deprecated) > 0 { // warn once per section
+					fmt.Fprintf(os.Stderr, "%s: deprecated (%s) parameter section; %s\n",
+						filepath.Base(os.Args[0]), name, deprecated)
+					deprecated = ""
+				}
+			}
+
+			if fail != nil {
+				err := p.fail(&FieldError{Field: v.Type().Field(j).Name, Tag: v.Type().Field(j).Tag, Err: fail})
+				if err != nil {
+					return err
+				}
+				continue
 			}
 
 			// mirror field NAME:VALUE from struct to the os.Environment table
@@ -364,12 +985,17 @@ func (p *Options) parse(cfg ...interface{}) {
 		}
 
 	}
+
+	return nil
 }
 
 // setField supports the string, bool, int, int64, uint, uint64 types as
-// well as types derived from them (eg. time.Duration is int64); otherwise
-// the field is ignored as nothing can be set
-func (p *Options) setField(v reflect.Value, s string) (string, bool) {
+// well as types derived from them (eg. time.Duration is int64 and also
+// accepts the 30s, 1h30m form; a bare integer is nanoseconds), slices of
+// them split on sep (default: comma) and maps of them from k=v pairs split
+// on sep (eg. env=prod,region=us); otherwise the field is ignored as
+// nothing can be set
+func (p *Options) setField(v reflect.Value, s, sep string) (string, bool) {
 
 	var ok bool
 
@@ -379,7 +1005,11 @@ func (p *Options) setField(v reflect.Value, s string) (string, bool) {
 		ok = len(s) > 0
 
 	case reflect.Int, reflect.Int64:
-		n, _ := strconv.ParseInt(s, 10, 0)
+		n, err := strconv.ParseInt(s, 10, 0)
+		if err != nil && v.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, _ := time.ParseDuration(s) // 30s, 1h30m, 500ms
+			n = int64(d)
+		}
 		v.SetInt(n)
 		ok = len(s) > 0 // accept 0 as valid
 
@@ -389,16 +1019,46 @@ func (p *Options) setField(v reflect.Value, s string) (string, bool) {
 		ok = len(s) > 0 // accept 0 as valid
 
 	case reflect.Bool:
-		var value bool
-		switch strings.ToLower(s) {
-		//case "off", "no", "false", "0":
-		case "on", "yes", "ok", "true", "1":
-			value = true
-			fallthrough
-		default:
-			v.SetBool(value)
-			ok = true
+		v.SetBool(boolean(s))
+		ok = true // explicit false is recognized
+
+	case reflect.Slice:
+		if len(sep) == 0 {
+			sep = ","
+		}
+		list := reflect.MakeSlice(v.Type(), 0, 0) // empty, not nil
+		if len(s) > 0 {
+			for _, item := range strings.Split(s, sep) {
+				e := reflect.New(v.Type().Elem()).Elem()
+				p.setField(e, strings.TrimSpace(item), sep)
+				list = reflect.Append(list, e)
+			}
+		}
+		v.Set(list)
+		ok = len(s) > 0
+
+	case reflect.Map:
+		if len(sep) == 0 {
+			sep = ","
+		}
+		set := reflect.MakeMap(v.Type())
+		if len(s) > 0 {
+			for _, item := range strings.Split(s, sep) {
+				kv := strings.SplitN(item, "=", 2)
+				if len(kv) == 1 {
+					kv = strings.SplitN(item, ":", 2)
+				}
+				k := reflect.New(v.Type().Key()).Elem()
+				e := reflect.New(v.Type().Elem()).Elem()
+				p.setField(k, strings.TrimSpace(kv[0]), sep)
+				if len(kv) == 2 {
+					p.setField(e, strings.TrimSpace(kv[1]), sep)
+				}
+				set.SetMapIndex(k, e)
+			}
 		}
+		v.Set(set)
+		ok = len(s) > 0
 
 		//default:
 		// unsupported, no-op
@@ -410,3 +1070,29 @@ func (p *Options) setField(v reflect.Value, s string) (string, bool) {
 
 	return s, ok
 }
+
+// boolean reports the bool value of s for every parse engine; on, yes, ok,
+// true, 1, enabled are true and anything else (eg. off, no, false, 0,
+// disabled) is false
+func boolean(s string) bool {
+
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "on", "yes", "ok", "true", "1", "enabled":
+		return true
+	}
+
+	return false
+}
+
+// expand replaces the ${VAR} and $VAR environment references in tag:default
+// and conf values before they are set; $$ is a literal $
+//
+//	Cache string `default:"${HOME}/.cache/app"`
+func expand(s string) string {
+	return os.Expand(s, func(key string) string {
+		if key == "$" {
+			return "$"
+		}
+		return os.Getenv(key)
+	})
+}
@@ -0,0 +1,228 @@
+package env
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseTwice(t *testing.T) {
+
+	var cfg struct {
+		Name   string
+		Number int `default:"5"`
+		Debug  bool
+		Tags   []string
+	}
+
+	o := Options{Silent: true, Args: []string{"app", "-name", "first", "-number", "9", "-debug", "-tags", "a,b"}}
+	if err := o.Parse(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "first" || cfg.Number != 9 || !cfg.Debug || len(cfg.Tags) != 2 {
+		t.Fatalf("first parse %+v", cfg)
+	}
+
+	o.Args = []string{"app", "-tags", "c"}
+	if err := o.Parse(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "" || cfg.Number != 5 || cfg.Debug || len(cfg.Tags) != 1 || cfg.Tags[0] != "c" {
+		t.Fatalf("second parse kept prior values %+v", cfg)
+	}
+}
+
+func TestParsePreset(t *testing.T) {
+
+	var cfg struct {
+		Host string
+		Port int
+	}
+	cfg.Host, cfg.Port = "preset", 42
+
+	o := Options{Silent: true, Args: []string{"app"}}
+	if err := o.Parse(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Host != "preset" || cfg.Port != 42 {
+		t.Fatalf("preset values lost %+v", cfg)
+	}
+
+	o.Args = []string{"app", "-port", "9"}
+	if err := o.Parse(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	o.Args = []string{"app"}
+	if err := o.Parse(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Host != "preset" || cfg.Port != 42 {
+		t.Fatalf("preset values not restored %+v", cfg)
+	}
+}
+
+func TestOrderOmitted(t *testing.T) {
+
+	var cfg struct {
+		Action string `env:"order"`
+		Port   int    `default:"80"`
+	}
+
+	o := Options{Silent: true, Args: []string{"app", "-port", "9090"}}
+	if err := o.Parse(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Action != "" || cfg.Port != 9090 {
+		t.Fatalf("flag value consumed as order %+v", cfg)
+	}
+
+	o.Args = []string{"app", "run", "-port", "9090"}
+	if err := o.Parse(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Action != "run" || cfg.Port != 9090 {
+		t.Fatalf("order %+v", cfg)
+	}
+}
+
+func TestOrderAfterSwitch(t *testing.T) {
+
+	var cfg struct {
+		File    string `env:"order"`
+		Verbose bool
+		Level   int
+	}
+
+	o := Options{Silent: true, Args: []string{"app", "-verbose", "file.txt", "-level", "2"}}
+	if err := o.Parse(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.File != "file.txt" || !cfg.Verbose || cfg.Level != 2 {
+		t.Fatalf("positional dropped %+v", cfg)
+	}
+}
+
+func TestOrderAfterFlag(t *testing.T) {
+
+	var cfg struct {
+		Port   int
+		Action string `env:"order"`
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = w
+	o := Options{Silent: true, Args: []string{"app", "run"}}
+	err = o.Parse(&cfg)
+	os.Stderr = stderr
+	w.Close()
+	b, _ := io.ReadAll(r)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "order (action) parameter declared after (port) parameter") {
+		t.Fatalf("no warning %q", b)
+	}
+}
+
+func TestSkippedFields(t *testing.T) {
+
+	var cfg struct {
+		Port int `default:"80"`
+		z    int
+		Path string   `env:"-" default:"x"`
+		Seg  []string `env:"-"`
+	}
+	cfg.z, cfg.Path = 1, "keep"
+
+	o := Options{Silent: true, Args: []string{"app", "-port", "9", "-path", "y", "-seg", "a"}}
+	if err := o.Parse(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != 9 || cfg.z != 1 || cfg.Path != "keep" || cfg.Seg != nil {
+		t.Fatalf("%+v", cfg)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	_, err = NewEnvE(&Options{Silent: true, Conf: "/nonexistent", Args: []string{"app", "help"}}, &cfg)
+	os.Stdout = stdout
+	w.Close()
+	b, _ := io.ReadAll(r)
+
+	if !errors.Is(err, ErrHelp) {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), " port ") {
+		t.Fatalf("port not listed %q", b)
+	}
+	for _, name := range []string{" z ", " path ", " seg "} {
+		if strings.Contains(string(b), name) {
+			t.Fatalf("%s listed %q", name, b)
+		}
+	}
+}
+
+func TestWriteConfRoundTrip(t *testing.T) {
+
+	type config struct {
+		Name string
+		Srv  struct {
+			Port int
+			TLS  struct{ On bool }
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "app.conf")
+	os.WriteFile(path, []byte("name = old\n"), 0600)
+
+	var out config
+	out.Name, out.Srv.Port, out.Srv.TLS.On = "app", 5, true
+	o := Options{Silent: true, Conf: path, Args: []string{"app"}}
+	if err := o.WriteConf(path, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{path, path + ".bak"} {
+		if info, err := os.Stat(name); err != nil || info.Mode().Perm() != 0600 {
+			t.Fatalf("%s mode %v %v", name, info.Mode(), err)
+		}
+	}
+
+	var in config
+	if err := o.Parse(&in); err != nil {
+		t.Fatal(err)
+	}
+	if in != out {
+		t.Fatalf("round trip %+v", in)
+	}
+}
+
+func TestPersistSaveUnencodable(t *testing.T) {
+
+	p := Persist(filepath.Join(t.TempDir(), "state"))
+	if !p.Save(map[string]int{"a": 1}) {
+		t.Fatal("save")
+	}
+	if p.Save(make(chan int)) {
+		t.Fatal("unencodable value saved")
+	}
+
+	var m map[string]int
+	if !p.LoadKeep(&m, nil) || m["a"] != 1 {
+		t.Fatalf("prior file not intact %v", m)
+	}
+	if tmp, _ := filepath.Glob(string(p) + ".persist.*"); len(tmp) > 0 {
+		t.Fatalf("temp file left %v", tmp)
+	}
+}
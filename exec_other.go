@@ -0,0 +1,8 @@
+//go:build !unix
+
+package env
+
+import "errors"
+
+// reexec is not supported on this platform
+func reexec() error { return errors.New("re-exec unsupported") }
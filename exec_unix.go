@@ -0,0 +1,21 @@
+//go:build unix
+
+package env
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// reexec replaces the process image with the binary at os.Args[0] using
+// the same args and environment; the pid is unchanged
+func reexec() error {
+
+	path, err := exec.LookPath(os.Args[0])
+	if err != nil {
+		return err
+	}
+
+	return syscall.Exec(path, os.Args, os.Environ())
+}
@@ -0,0 +1,36 @@
+package env
+
+// ErrorKind classifies the failures that terminate the process so each
+// class can be mapped to a distinct exit code for a supervisor
+//
+//	ExitConfig:        missing required, invalid or unresolved value (default: 0)
+//	ExitMisconfigured: unsupported config interface or managed type (default: 1)
+//	ExitDependency:    managed Start(ctx) error bootstrap failure (default: 1)
+//	ExitValidate:      validate subcommand found invalid parameters (default: 1)
+//	ExitPanic:         managed process panic recovered into a shutdown (default: 1)
+type ErrorKind int
+
+const (
+	ExitConfig ErrorKind = iota
+	ExitMisconfigured
+	ExitDependency
+	ExitValidate
+	ExitPanic
+)
+
+// exitCode mapping; see ErrorKind for the defaults
+var exitCode = map[ErrorKind]int{
+	ExitConfig:        0,
+	ExitMisconfigured: 1,
+	ExitDependency:    1,
+	ExitValidate:      1,
+	ExitPanic:         1,
+}
+
+// SetExitCode customizes the exit code for the failure kind; set before
+// calling NewEnv or NewGraceful
+//
+//	env.SetExitCode(env.ExitConfig, 10)
+//	env.SetExitCode(env.ExitDependency, 20)
+//	env.SetExitCode(env.ExitPanic, 30)
+func SetExitCode(kind ErrorKind, code int) { exitCode[kind] = code }
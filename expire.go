@@ -2,9 +2,12 @@ package env
 
 import (
 	"context"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 	"time"
 )
 
@@ -20,19 +23,60 @@ import (
 
 // Expire struct
 type Expire struct {
-	CheckOn time.Duration // frequency of checks (default: hourly)
-	item    []struct {    // directory targets
-		Path string
-		TTL  time.Duration
-	}
-	silent bool
+	CheckOn  time.Duration                 // frequency of checks (default: hourly)
+	OnExpire func(path string, size int64) // called for each removed file; optional
+	item     []expire                      // directory targets
+	silent   bool
+	dry      bool
+	prune    bool
+	veto     func(path string, info fs.FileInfo) error
+	mu       sync.Mutex
+	stats    ExpireStats // the last run
+}
+
+// ExpireStats reports the files removed and bytes reclaimed by an Expire run
+type ExpireStats struct {
+	Files int
+	Bytes int64
+}
+
+// expire directory target
+type expire struct {
+	Path    string
+	TTL     time.Duration
+	Pattern string // filepath.Match file name pattern; empty matches all
+	Size    int64  // directory byte budget; evicts oldest first
+	Recurse bool   // walk the subdirectories
 }
 
 // Silent flag toggle for env.Expire, writes logs on os.Stderr (default: on)
 func (ex *Expire) Silent() *Expire { ex.silent = !ex.silent; return ex }
 
+// Veto registers fn to run just before each file is removed; an error
+// keeps the file, eg. for an audit log or a remote tombstone that failed
+//
+//	expire.Veto(func(path string, info fs.FileInfo) error { return tombstone(path) })
+func (ex *Expire) Veto(fn func(path string, info fs.FileInfo) error) *Expire {
+	ex.veto = fn
+	return ex
+}
+
+// DryRun flag toggle for env.Expire, logs the files that would expire and
+// tallies Reclaimed without removing anything (default: off)
+func (ex *Expire) DryRun() *Expire { ex.dry = !ex.dry; return ex }
+
+// Prune flag toggle for env.Expire, removes the subdirectories of an
+// AddRecursive path left empty by the expiration (default: off)
+func (ex *Expire) Prune() *Expire { ex.prune = !ex.prune; return ex }
+
 // Add will register a directory/path with customized age timeframe (default: 24hr expiration)
 func (ex *Expire) Add(ttl *time.Duration, path ...string) *Expire {
+	return ex.AddPattern(ttl, "", path...)
+}
+
+// AddPattern will register a directory/path as Add that only expires the
+// files with a name matching the filepath.Match pattern; eg. *.tmp
+func (ex *Expire) AddPattern(ttl *time.Duration, pattern string, path ...string) *Expire {
 
 	if ttl == nil || *ttl == 0 {
 		ttl24hr := time.Hour
@@ -41,12 +85,25 @@ func (ex *Expire) Add(ttl *time.Duration, path ...string) *Expire {
 
 	for i := range path {
 		if len(path[i]) > 0 {
-			ex.item = append(ex.item, struct {
-				Path string
-				TTL  time.Duration
-			}{path[i], *ttl})
+			ex.item = append(ex.item, expire{Path: path[i], TTL: *ttl, Pattern: pattern})
+			if !ex.silent {
+				log.Printf("expire: add %s ttl[%s]", filepath.Join(filepath.Base(path[i]), pattern), *ttl)
+			}
+		}
+	}
+
+	return ex
+}
+
+// AddSize will register a directory/path with a byte budget; the oldest
+// files are expired until the directory is under maxBytes
+func (ex *Expire) AddSize(maxBytes int64, path ...string) *Expire {
+
+	for i := range path {
+		if len(path[i]) > 0 && maxBytes > 0 {
+			ex.item = append(ex.item, expire{Path: path[i], Size: maxBytes})
 			if !ex.silent {
-				log.Printf("expire: add %s ttl[%s]", filepath.Base(path[i]), *ttl)
+				log.Printf("expire: add %s size[%d]", filepath.Base(path[i]), maxBytes)
 			}
 		}
 	}
@@ -54,6 +111,20 @@ func (ex *Expire) Add(ttl *time.Duration, path ...string) *Expire {
 	return ex
 }
 
+// AddRecursive will register a directory/path tree as Add that also expires
+// the files in the subdirectories, eg. {path}/{shard}/{file}; see Prune to
+// remove the subdirectories left empty by the expiration
+func (ex *Expire) AddRecursive(ttl *time.Duration, path ...string) *Expire {
+
+	n := len(ex.item)
+	ex.Add(ttl, path...)
+	for i := n; i < len(ex.item); i++ {
+		ex.item[i].Recurse = true
+	}
+
+	return ex
+}
+
 // Start expire service manger to check for expired files periodically
 // based on expire.CheckOn setting (default: check hourly, expire after 24hr)
 func (ex *Expire) Start(ctx context.Context) {
@@ -79,17 +150,32 @@ func (ex *Expire) Start(ctx context.Context) {
 // Expire will run the registered expiration processes
 func (ex *Expire) Expire() *Expire {
 
+	ex.mu.Lock()
+	ex.stats = ExpireStats{}
+	ex.mu.Unlock()
+
 	now := time.Now().Truncate(time.Second)
 	for i := range ex.item {
+		if ex.item[i].Size > 0 {
+			ex.evict(ex.item[i])
+			continue
+		}
+		if ex.item[i].Recurse {
+			ex.walk(ex.item[i], now)
+			continue
+		}
 		content, _ := os.ReadDir(ex.item[i].Path)
 		for j := range content {
+			if ok, _ := filepath.Match(ex.item[i].Pattern, content[j].Name()); !ok && len(ex.item[i].Pattern) > 0 {
+				continue // not matched
+			}
 			if content[j].Type().IsRegular() {
-				info, _ := os.Stat(filepath.Join(ex.item[i].Path, content[j].Name()))
-				if !info.IsDir() && info.ModTime().Add(ex.item[i].TTL).Before(now) {
-					if !ex.silent {
+				info, err := content[j].Info()
+				if err == nil && info.ModTime().Add(ex.item[i].TTL).Before(now) {
+					if !ex.silent && !ex.dry {
 						log.Println("expire:", info.Name())
 					}
-					os.Remove(filepath.Join(ex.item[i].Path, info.Name()))
+					ex.remove(ex.item[i].Path, info)
 				}
 			}
 		}
@@ -97,3 +183,104 @@ func (ex *Expire) Expire() *Expire {
 
 	return ex
 }
+
+// walk removes the expired files in the directory tree and then, when
+// pruning, the subdirectories left empty, deepest first
+func (ex *Expire) walk(item expire, now time.Time) {
+
+	var dirs []string
+	filepath.WalkDir(item.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if ok, _ := filepath.Match(item.Pattern, d.Name()); !ok && len(item.Pattern) > 0 {
+			return nil // not matched
+		}
+		info, err := d.Info()
+		if err == nil && info.ModTime().Add(item.TTL).Before(now) {
+			if !ex.silent && !ex.dry {
+				log.Println("expire:", path)
+			}
+			if ex.remove(filepath.Dir(path), info) && ex.prune && !ex.dry && filepath.Dir(path) != filepath.Clean(item.Path) {
+				dirs = append(dirs, filepath.Dir(path))
+			}
+		}
+		return nil
+	})
+
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, dir := range dirs {
+		for dir != filepath.Clean(item.Path) && os.Remove(dir) == nil { // fails when not empty
+			dir = filepath.Dir(dir)
+		}
+	}
+}
+
+// evict removes the oldest files until the directory is under the budget
+func (ex *Expire) evict(item expire) {
+
+	var total int64
+	var files []fs.FileInfo
+	content, _ := os.ReadDir(item.Path)
+	for j := range content {
+		if content[j].Type().IsRegular() {
+			if info, err := content[j].Info(); err == nil {
+				files = append(files, info)
+				total += info.Size()
+			}
+		}
+	}
+
+	sort.Slice(files, func(a, b int) bool { return files[a].ModTime().Before(files[b].ModTime()) })
+	for j := 0; j < len(files) && total > item.Size; j++ {
+		if !ex.silent && !ex.dry {
+			log.Println("expire: evict", files[j].Name())
+		}
+		if ex.remove(item.Path, files[j]) {
+			total -= files[j].Size()
+		}
+	}
+}
+
+// remove the file and tally the reclaimed bytes for Reclaimed and OnExpire
+func (ex *Expire) remove(dir string, info fs.FileInfo) bool {
+
+	path := filepath.Join(dir, info.Name())
+	switch {
+	case ex.veto != nil && !ex.dry && ex.veto(path, info) != nil:
+		return false // kept
+	case ex.dry:
+		if !ex.silent {
+			log.Println("expire (dry-run):", path)
+		}
+	case os.Remove(path) != nil:
+		return false
+	}
+
+	ex.mu.Lock()
+	ex.stats.Files++
+	ex.stats.Bytes += info.Size()
+	ex.mu.Unlock()
+
+	if ex.OnExpire != nil && !ex.dry {
+		ex.OnExpire(path, info.Size())
+	}
+
+	return true
+}
+
+// Reclaimed reports the files removed and the bytes reclaimed by the last run
+func (ex *Expire) Reclaimed() (count int, bytes int64) {
+	stats := ex.LastRun()
+	return stats.Files, stats.Bytes
+}
+
+// LastRun reports the ExpireStats of the last run, including the periodic
+// runs of Start; eg. for capacity metrics
+func (ex *Expire) LastRun() ExpireStats {
+
+	ex.mu.Lock()
+	defer ex.mu.Unlock()
+
+	return ex.stats
+}
@@ -8,6 +8,7 @@ import (
 	"os/signal"
 	"path/filepath"
 	"reflect"
+	"runtime/debug"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -32,11 +33,42 @@ type graceful struct {
 	cancel                  context.CancelFunc
 	silent                  bool
 	name                    string
-	stop, wait, bye         atomic.Bool
+	stop, wait, bye, ready  atomic.Bool
+	upgrade, reexec         atomic.Bool  // SIGHUP re-exec; requested
+	exit                    atomic.Int32 // process exit code
+	ring                    *ring        // flight recorder
+	crash                   string       // flight recorder dump path
+	mu                      sync.Mutex
+	ack                     map[string]chan struct{} // acknowledgement barrier
+	expect                  []string
+	ackTimeout              time.Duration
+	timeout                 time.Duration         // shutdown timeout; 0 waits forever
+	soft                    time.Duration         // soft Cancel grace window
+	pending                 chan struct{}         // soft Cancel in the grace window; closed on Resume
+	workers                 []*worker             // managed processes
+	reload                  []func()              // OnReload callbacks
+	sem                     chan struct{}         // bootstrap concurrency limit
+	ordered                 bool                  // LIFO shutdown
+	sig                     chan os.Signal        // shutdown signal source
+	osExit                  func(int)             // process exit; os.Exit
+	sleep                   func(d time.Duration) // clock delay; time.Sleep
+	timer                   clock                 // clock timer; time.NewTimer
+}
+
+// Graceful is the lifecycle controller interface implemented by NewGraceful
+// so callers and tests can accept or substitute the controller
+type Graceful interface {
+	Context() context.Context
+	Cancel()
+	Done()
+	Wait()
+	Stop()
+	Manager(obj ...interface{})
 }
 
 // NewGraceful configurator returns *graceful and starts the shutdown controller to
-// capture (os.Interrupt, syscall.SIGTERM, syscall.SIGHUP) signals and waits on
+// capture (os.Interrupt, syscall.SIGTERM, syscall.SIGHUP) signals, where SIGHUP
+// is a reload when graceful.OnReload callbacks are registered, and waits on
 // the <-graceful.context.Done() for a signal and waits for the graceful.Manager
 // controller wgShutdown to confirm all managed processes and completed tasks before
 // the program terminates execution
@@ -47,16 +79,28 @@ func NewGraceful() *graceful {
 	g.wgShutdown = new(sync.WaitGroup)
 	g.ctx, g.cancel = context.WithCancel(context.Background())
 	g.name = filepath.Base(os.Args[0])
+	g.sig = make(chan os.Signal, 1)
+	g.osExit = os.Exit
+	g.sleep = time.Sleep
+	g.timer = newTimer
 
+	signal.Notify(g.sig, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
 	go func(g *graceful) {
-		sig := make(chan os.Signal, 1)
-		signal.Notify(sig, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
-		select {
-		case <-g.ctx.Done():
-		case j := <-sig:
-			log.Printf("%s: %s shutdown", g.name, j)
-			signal.Stop(sig)
-			g.cancel()
+		for g.ctx.Err() == nil {
+			select {
+			case <-g.ctx.Done():
+			case j := <-g.sig:
+				if j == syscall.SIGHUP && !g.upgrade.Load() && g.reloads() {
+					g.reloaded() // SIGHUP is reload with OnReload callbacks
+					continue
+				}
+				log.Printf("%s: %s shutdown", g.name, j)
+				if j == syscall.SIGHUP && g.upgrade.Load() {
+					g.reexec.Store(true)
+				}
+				signal.Stop(g.sig)
+				g.cancel()
+			}
 		}
 		g.Wait()
 	}(g)
@@ -64,9 +108,152 @@ func NewGraceful() *graceful {
 	return g
 }
 
+// Signal delivers sig to the shutdown controller as if it was received from
+// the os; used to drive the lifecycle deterministically in tests
+func (g *graceful) Signal(sig os.Signal) {
+	select {
+	case g.sig <- sig:
+	default: // pending
+	}
+}
+
+// Signals replaces the captured shutdown signals (default: os.Interrupt,
+// syscall.SIGTERM, syscall.SIGHUP) and may be called at any time; none
+// leaves graceful.Signal and graceful.Cancel as the only shutdown sources
+//
+//	grace := env.NewGraceful().Signals(os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT)
+func (g *graceful) Signals(sig ...os.Signal) *graceful {
+
+	signal.Stop(g.sig)
+	if len(sig) > 0 && g.ctx.Err() == nil {
+		signal.Notify(g.sig, sig...)
+	}
+
+	return g
+}
+
+// Hooks replaces the process exit and the clock delay used by the controller
+// so the init, ready, shutdown flow can be exercised without terminating the
+// process or waiting on the wall-clock; nil keeps the current hook; see Clock
+// for the timeouts and graceful.Signals() to detach from the os signals
+//
+//	grace := env.NewGraceful().Signals().Hooks(func(code int) { exit <- code }, func(time.Duration) {})
+func (g *graceful) Hooks(exit func(code int), sleep func(d time.Duration)) *graceful {
+	if exit != nil {
+		g.osExit = exit
+	}
+	if sleep != nil {
+		g.sleep = sleep
+	}
+	return g
+}
+
+// Clock replaces the timer behind the bootstrap, shutdown, acknowledgement,
+// soft Cancel and Retry timeouts so a test can fire them on demand; the
+// timer returns the fire channel and a stop func; nil keeps the current timer
+//
+//	fire := make(chan time.Time)
+//	grace.Clock(func(time.Duration) (<-chan time.Time, func() bool) { return fire, func() bool { return true } })
+func (g *graceful) Clock(timer func(d time.Duration) (<-chan time.Time, func() bool)) *graceful {
+	if timer != nil {
+		g.timer = timer
+	}
+	return g
+}
+
+// clock starts a timer returning the fire channel and the stop func
+type clock func(d time.Duration) (<-chan time.Time, func() bool)
+
+// newTimer is the default clock timer
+func newTimer(d time.Duration) (<-chan time.Time, func() bool) {
+	t := time.NewTimer(d)
+	return t.C, t.Stop
+}
+
+// Upgrade toggles a re-exec of the binary at os.Args[0] when the shutdown
+// is from SIGHUP; the managed processes are drained as for any shutdown and
+// the process image is then replaced in place with the same args, env and
+// pid, picking up a newly deployed binary at the same path (default: off)
+func (g *graceful) Upgrade() *graceful { g.upgrade.Store(!g.upgrade.Load()); return g }
+
 // Silent flag toggle for env.Graceful, writes logs on os.Stderr (default: on)
 func (g *graceful) Silent() *graceful { g.silent = !g.silent; return g }
 
+// OnSignal registers fn to run each time sig is received; these signals are
+// handled apart from the shutdown signals and do not cancel the context
+//
+//	grace.OnSignal(env.SigUSR1, func() { pprof.Lookup("goroutine").WriteTo(os.Stderr, 1) })
+func (g *graceful) OnSignal(sig os.Signal, fn func()) *graceful {
+
+	if sig == nil || fn == nil {
+		return g // unsupported on platform
+	}
+
+	go func() {
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, sig)
+		defer signal.Stop(ch)
+		for {
+			select {
+			case <-g.ctx.Done():
+				return
+			case <-ch:
+				fn()
+			}
+		}
+	}()
+
+	return g
+}
+
+// OnReload registers fn to run when SIGUSR1 or SIGHUP is received without
+// cancelling the context; eg. to re-read the /etc/{name}/{name}.conf file;
+// SIGHUP remains a shutdown signal when no callback is registered or the
+// graceful.Upgrade re-exec is enabled
+//
+//	grace.OnReload(func() { env.Configure(&param) })
+func (g *graceful) OnReload(fn func()) *graceful {
+
+	if fn == nil {
+		return g
+	}
+
+	g.mu.Lock()
+	g.reload = append(g.reload, fn)
+	first := len(g.reload) == 1
+	g.mu.Unlock()
+
+	if first {
+		g.OnSignal(SigUSR1, g.reloaded)
+	}
+
+	return g
+}
+
+// reloads reports whether OnReload callbacks are registered
+func (g *graceful) reloads() bool {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return len(g.reload) > 0
+}
+
+// reloaded calls the OnReload callbacks in the order registered
+func (g *graceful) reloaded() {
+
+	g.mu.Lock()
+	reload := g.reload
+	g.mu.Unlock()
+
+	if !g.silent {
+		log.Printf("%s: reload", g.name)
+	}
+	for _, fn := range reload {
+		fn()
+	}
+}
+
 // Context is the graceful.context exported from the graceful manager for
 // external use with processes not under the graceful.Manager controller
 // that still need signaling to exit without g.wgShutdown reporting confirmation
@@ -74,18 +261,128 @@ func (g *graceful) Context() context.Context { return g.ctx }
 
 // Cancel calls the graceful.context cancel() function; this function can be pass
 // for external use with processes not under teh graceful.Manager controller for
-// processes that require global termination signaling
-func (g *graceful) Cancel() { g.cancel() }
+// processes that require global termination signaling; in soft mode the cancel
+// is deferred by the grace window and may be aborted with graceful.Resume
+func (g *graceful) Cancel() {
+
+	if g.soft == 0 {
+		g.cancel()
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.pending != nil || g.ctx.Err() != nil {
+		return // in the grace window or shutdown
+	}
+	if !g.silent {
+		log.Printf("%s: shutdown in %s", g.name, g.soft)
+	}
+
+	resume := make(chan struct{})
+	fire, stop := g.timer(g.soft)
+	go func() {
+		select {
+		case <-resume:
+			stop()
+			return
+		case <-g.ctx.Done(): // signal or Stop
+			stop()
+			return
+		case <-fire:
+		}
+		g.mu.Lock()
+		if g.pending != resume {
+			g.mu.Unlock()
+			return // resumed
+		}
+		g.pending = nil
+		g.mu.Unlock()
+		g.cancel() // point of no return
+	}()
+	g.pending = resume
+}
+
+// Soft sets the grace window of a soft graceful.Cancel; the shutdown only
+// begins when the window passes without a graceful.Resume, and once the
+// context is cancelled the managed processes tear down and the shutdown
+// can no longer be resumed; signals and graceful.Stop are never deferred
+//
+//	grace := env.NewGraceful().Soft(time.Second * 30)
+//	grace.Cancel() // idle
+//	...
+//	grace.Resume() // work arrived
+func (g *graceful) Soft(window time.Duration) *graceful { g.soft = window; return g }
+
+// Resume aborts a soft graceful.Cancel within the grace window and reports
+// whether the shutdown was aborted; false when the shutdown already began
+func (g *graceful) Resume() bool {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.pending == nil {
+		return false
+	}
+	close(g.pending)
+	g.pending = nil
+	if !g.silent {
+		log.Printf("%s: shutdown resumed", g.name)
+	}
+
+	return true
+}
 
 // Done blocks until all graceful.Manager bootstaps are complete
 func (g *graceful) Done() {
 	// delay timer to allow graceful.Manager to register
 	// at least one wgBootstrap.Add(1) event
-	time.Sleep(time.Millisecond * 250)
+	g.sleep(time.Millisecond * 250)
 	g.wgBootstrap.Wait()
+	g.ready.Store(true)
+	if !g.silent {
+		log.Printf("%s: bootstrap complete", g.name)
+	}
+}
+
+// DoneTimeout is graceful.Done bounded by d; reports false and logs the
+// managed processes with a pending bootstrap when d passes first, eg. a
+// Start(ctx, *sync.WaitGroup) that never calls Done
+//
+//	if !grace.DoneTimeout(time.Minute) {
+//		grace.Stop()
+//	}
+func (g *graceful) DoneTimeout(d time.Duration) bool {
+
+	g.sleep(time.Millisecond * 250)
+
+	done := make(chan struct{})
+	go func() { g.wgBootstrap.Wait(); close(done) }()
+
+	fire, stop := g.timer(d)
+	defer stop()
+
+	select {
+	case <-done:
+	case <-fire:
+		log.Printf("%s: bootstrap timeout %s", g.name, d)
+		g.mu.Lock()
+		for _, w := range g.workers {
+			if !w.up {
+				log.Printf("%s: waiting on %s", g.name, w.name)
+			}
+		}
+		g.mu.Unlock()
+		return false
+	}
+
+	g.ready.Store(true)
 	if !g.silent {
 		log.Printf("%s: bootstrap complete", g.name)
 	}
+
+	return true
 }
 
 // Wait blocks on the graceful context and waits for bootstaps to terminate to cleanly exit
@@ -93,8 +390,10 @@ func (g *graceful) Wait() {
 	if g.wait.CompareAndSwap(false, true) { // ignore recurrent calls
 
 		g.wgBootstrap.Wait() // allow bootstraps to complete
+		g.ready.Store(true)  // report readiness
 		<-g.ctx.Done()       // block and wait on context
-		g.wgShutdown.Wait()  // allow shutdowns to complete
+		g.shutdown()         // allow shutdowns to complete
+		g.barrier()          // allow expected acknowledgements
 
 		if g.bye.CompareAndSwap(false, true) { // ignore recurrent calls
 			if !g.silent {
@@ -102,12 +401,131 @@ func (g *graceful) Wait() {
 				log.Printf(" %s: bye", g.name)
 				log.Printf("|%s|", strings.Repeat("-", 40))
 			}
-			time.Sleep(time.Millisecond * 250)
-			os.Exit(0)
+			g.sleep(time.Millisecond * 250)
+			if g.reexec.Load() {
+				if err := reexec(); err != nil { // no return on success
+					log.Printf("%s: upgrade %s", g.name, err)
+				}
+			}
+			g.osExit(int(g.exit.Load()))
 		}
 	}
 }
 
+// SetShutdownTimeout bounds the wait on the managed processes to complete
+// their shutdown; when the deadline passes the timeout and the managed
+// processes still pending are logged and the process exits anyway
+// (default: 0, waits forever)
+func (g *graceful) SetShutdownTimeout(d time.Duration) *graceful { g.timeout = d; return g }
+
+// shutdown waits on the managed processes, bounded by the shutdown timeout
+func (g *graceful) shutdown() {
+
+	if g.ordered {
+		go g.unwind()
+	}
+
+	if g.timeout == 0 {
+		g.wgShutdown.Wait()
+		return
+	}
+
+	done := make(chan struct{})
+	go func() { g.wgShutdown.Wait(); close(done) }()
+
+	fire, stop := g.timer(g.timeout)
+	defer stop()
+
+	select {
+	case <-done:
+	case <-fire:
+		log.Printf("%s: shutdown timeout %s", g.name, g.timeout)
+		g.mu.Lock()
+		for _, w := range g.workers {
+			if !w.done {
+				log.Printf("%s: waiting on %s", g.name, w.name)
+			}
+		}
+		g.mu.Unlock()
+	}
+}
+
+// Expect configures the shutdown to wait for the named acknowledgements
+// from graceful.Ack before the bye phase, for no longer than timeout in
+// total (default: 1min); eg. ordered pipeline drain semantics
+//
+//	grace.Expect(time.Second*30, "producer", "drained")
+func (g *graceful) Expect(timeout time.Duration, name ...string) *graceful {
+
+	if timeout == 0 {
+		timeout = time.Minute // default
+	}
+
+	g.mu.Lock()
+	g.ackTimeout = timeout
+	g.expect = append(g.expect, name...)
+	g.mu.Unlock()
+
+	return g
+}
+
+// Ack records the named acknowledgement; recurrent calls are ignored
+func (g *graceful) Ack(name string) {
+
+	ch := g.acknowledge(name)
+
+	g.mu.Lock()
+	select {
+	case <-ch:
+	default:
+		close(ch)
+	}
+	g.mu.Unlock()
+}
+
+// acknowledge returns the named acknowledgement channel
+func (g *graceful) acknowledge(name string) chan struct{} {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.ack == nil {
+		g.ack = make(map[string]chan struct{})
+	}
+	if _, ok := g.ack[name]; !ok {
+		g.ack[name] = make(chan struct{})
+	}
+
+	return g.ack[name]
+}
+
+// barrier waits on the expected acknowledgements or the timeout
+func (g *graceful) barrier() {
+
+	g.mu.Lock()
+	expect := g.expect
+	g.mu.Unlock()
+
+	if len(expect) == 0 {
+		return
+	}
+
+	fire, stop := g.timer(g.ackTimeout)
+	defer stop()
+
+	for _, name := range expect {
+		select {
+		case <-g.acknowledge(name):
+		case <-fire:
+			log.Printf("%s: acknowledgement (%s) timeout", g.name, name)
+			return
+		}
+	}
+}
+
+// SetExit sets the process exit code used when graceful terminates
+func (g *graceful) SetExit(code int) *graceful { g.exit.Store(int32(code)); return g }
+
 // Stop cancels the graceful context and calls graceful.Wait
 func (g *graceful) Stop() {
 	if g.stop.CompareAndSwap(false, true) {
@@ -123,9 +541,25 @@ func (g *graceful) Stop() {
 // of specific signature types are supported
 //
 //	Start(ctx context.Context)
-//	Start(ctx context.Context) error
+//	Start(ctx context.Context) error // error sets the exit code
 //	Start(ctx context.Context, *sync.WaitGroup)
-func (g *graceful) Manager(obj ...interface{}) {
+//	Start(ctx context.Context, *sync.WaitGroup) error // error sets the exit code
+//
+// the managed process name is the lowercase struct type name; a panic in
+// a managed process is logged and recovered, and initiates the shutdown
+func (g *graceful) Manager(obj ...interface{}) { g.manage(nil, obj...) }
+
+// ManagerNamed is graceful.Manager with the name used to log, Restart and
+// report the managed process as pending on a shutdown timeout; eg. when
+// several instances of the same struct type are managed
+//
+//	grace.ManagerNamed("ingest-eu", &ingest)
+func (g *graceful) ManagerNamed(name string, obj interface{}) {
+	g.manage([]string{name}, obj)
+}
+
+// manage starts the managed processes; label overrides the name of obj[i]
+func (g *graceful) manage(label []string, obj ...interface{}) {
 
 	g.wgBootstrap.Add(1)
 	defer g.wgBootstrap.Done()
@@ -138,15 +572,28 @@ func (g *graceful) Manager(obj ...interface{}) {
 		if reflect.TypeOf(obj[i]).Kind() != reflect.Ptr ||
 			reflect.TypeOf(obj[i]).Elem().Kind() != reflect.Struct {
 			fmt.Fprintf(os.Stderr, "%s: unsupported type", g.name)
-			os.Exit(0)
+			g.osExit(exitCode[ExitMisconfigured])
+			g.wgBootstrap.Done() // exit hook returned
+			g.wgShutdown.Done()
+			continue
 		}
 
 		name := strings.ToLower(reflect.TypeOf(obj[i]).Elem().Name())
+		if i < len(label) && len(label[i]) > 0 {
+			name = label[i]
+		}
 
 		// object struct bootstrap signatures supported
 		//  Start(ctx context.Context) error
 		//  Start(ctx context.Context)
 		//  Start(ctx context.Context, *sync.WaitGroup)
+		//  Start(ctx context.Context, *sync.WaitGroup) error
+
+		w := &worker{name: name, restart: make(chan struct{}, 1), exited: make(chan struct{})}
+		g.mu.Lock()
+		g.workers = append(g.workers, w) // registration order
+		g.mu.Unlock()
+		g.child(w)
 
 		switch object := obj[i].(type) {
 
@@ -156,33 +603,44 @@ func (g *graceful) Manager(obj ...interface{}) {
 			// expects a simple bootstrap, if any, that simply needs to
 			// enter and remain in a loop or blocking on <-ctx.Done()
 			// with or without any shutdown process task sequences
-			go func() {
+			go g.run(w, func(ctx context.Context, first bool) {
 				if !g.silent {
 					log.Printf("%s: start", name)
 					defer log.Printf("%s: stop", name)
 				}
-				g.wgBootstrap.Done()
-				object.Start(g.ctx)
-				g.wgShutdown.Done()
-			}()
+				if first {
+					g.booted(w)
+				}
+				object.Start(ctx)
+			})
 
 		case interface {
 			Start(context.Context) error
 		}: // Start(ctx context.Context) error
 			// expects the bootstrap process to complete and return
-			// signaling the bootstrap has completed; hard exit on
-			// any bootstrap failure
-			go func() {
+			// signaling the bootstrap has completed; any failure sets
+			// the ExitDependency exit code and initiates the shutdown
+			go g.run(w, func(ctx context.Context, first bool) {
+				if first {
+					if !g.acquire(w) {
+						g.booted(w) // shutdown before a slot
+						return
+					}
+					defer g.release(w)
+				}
 				if !g.silent {
 					log.Printf("%s: start", name)
 				}
-				if err := object.Start(g.ctx); err != nil {
+				if err := object.Start(ctx); err != nil {
 					log.Printf("%s: %s", name, err)
-					os.Exit(0)
+					g.dump(err)
+					g.exit.CompareAndSwap(0, int32(exitCode[ExitDependency]))
+					g.cancel()
 				}
-				g.wgBootstrap.Done()
-				g.wgShutdown.Done()
-			}()
+				if first {
+					g.booted(w)
+				}
+			})
 
 		case interface {
 			Start(context.Context, *sync.WaitGroup)
@@ -190,19 +648,277 @@ func (g *graceful) Manager(obj ...interface{}) {
 			// expects a bootstrap process to signal when complete and
 			// then remain in a loop or blocking on <-ctx.Done() with
 			// or without any shutdown process task sequences
-			go func() {
+			go g.run(w, func(ctx context.Context, first bool) {
+				wg := new(sync.WaitGroup)
+				wg.Add(1)
+				if first { // report the bootstrap once
+					if !g.acquire(w) {
+						g.booted(w) // shutdown before a slot
+						return
+					}
+					go func() {
+						wg.Wait()
+						g.release(w)
+						g.booted(w)
+					}()
+				}
 				if !g.silent {
 					log.Printf("%s: start", name)
 					defer log.Printf("%s: stop", name)
 				}
-				object.Start(g.ctx, g.wgBootstrap)
-				g.wgShutdown.Done()
-			}()
+				object.Start(ctx, wg)
+			})
+
+		case interface {
+			Start(context.Context, *sync.WaitGroup) error
+		}: // Start(ctx context.Context, *sync.WaitGroup) error
+			// as above; any failure sets the ExitDependency exit code
+			// and initiates the shutdown, eg. a port that cannot bind
+			go g.run(w, func(ctx context.Context, first bool) {
+				wg := new(sync.WaitGroup)
+				wg.Add(1)
+				if first { // report the bootstrap once
+					if !g.acquire(w) {
+						g.booted(w) // shutdown before a slot
+						return
+					}
+					go func() {
+						wg.Wait()
+						g.release(w)
+						g.booted(w)
+					}()
+				}
+				if !g.silent {
+					log.Printf("%s: start", name)
+					defer log.Printf("%s: stop", name)
+				}
+				if err := object.Start(ctx, wg); err != nil {
+					log.Printf("%s: %s", name, err)
+					g.dump(err)
+					g.exit.CompareAndSwap(0, int32(exitCode[ExitDependency]))
+					g.cancel()
+					g.release(w)
+					g.booted(w) // failed before wg.Done
+				}
+			})
 
 		default:
 			fmt.Fprintf(os.Stderr, "%s: unsupported struct", g.name)
-			os.Exit(0) // hard stop
+			g.osExit(exitCode[ExitMisconfigured]) // hard stop
+			g.booted(w)                           // exit hook returned
+			g.mu.Lock()
+			w.done = true
+			g.mu.Unlock()
+			close(w.exited)
+			g.wgShutdown.Done()
+		}
+
+	}
+}
+
+// Concurrency limits the managed process bootstraps that run at the same
+// time to n so many dependencies do not flood an upstream at startup;
+// graceful.Done still waits on all of them (default: 0, unlimited)
+//
+//	grace := env.NewGraceful().Concurrency(4)
+func (g *graceful) Concurrency(n int) *graceful {
+	if n > 0 {
+		g.sem = make(chan struct{}, n)
+	}
+	return g
+}
+
+// acquire a bootstrap slot for the worker; false when the graceful context
+// is cancelled while waiting, always true when unlimited
+func (g *graceful) acquire(w *worker) bool {
+	if g.sem == nil {
+		return true
+	}
+	select {
+	case g.sem <- struct{}{}:
+		g.mu.Lock()
+		w.slot = true
+		g.mu.Unlock()
+		return true
+	case <-g.ctx.Done():
+		return false
+	}
+}
+
+// release the bootstrap slot held by the worker, if any; safe to call
+// more than once so the error and panic paths can release as well
+func (g *graceful) release(w *worker) {
+	g.mu.Lock()
+	held := w.slot
+	w.slot = false
+	g.mu.Unlock()
+	if held {
+		<-g.sem
+	}
+}
+
+// worker is a managed process with its own child context of the graceful
+// context so it can be restarted apart from the other managed processes
+type worker struct {
+	name    string
+	ctx     context.Context
+	cancel  context.CancelFunc
+	restart chan struct{}
+	exited  chan struct{}
+	boot    sync.Once // bootstrap reported
+	slot    bool      // bootstrap slot held
+	up      bool      // bootstrap complete
+	done    bool      // shutdown complete
+	halt    bool      // cancelled in the ordered shutdown
+}
+
+// booted reports the worker bootstrap complete once
+func (g *graceful) booted(w *worker) {
+	w.boot.Do(func() {
+		g.mu.Lock()
+		w.up = true
+		g.mu.Unlock()
+		g.wgBootstrap.Done()
+	})
+}
+
+// child replaces the worker context; in the ordered shutdown the context
+// is not derived from the graceful context and is cancelled in turn
+func (g *graceful) child(w *worker) context.Context {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	parent := g.ctx
+	if g.ordered {
+		parent = context.Background()
+	}
+	w.ctx, w.cancel = context.WithCancel(parent)
+	if w.halt {
+		w.cancel()
+	}
+
+	return w.ctx
+}
+
+// Ordered toggles the shutdown of the managed processes in the reverse of
+// the order registered; each is cancelled and waited on before the one
+// registered before it, eg. the http server drains before the db pool
+// closes (default: off, all are cancelled at once)
+func (g *graceful) Ordered() *graceful { g.ordered = !g.ordered; return g }
+
+// unwind cancels and waits on the managed processes in reverse order
+func (g *graceful) unwind() {
+
+	g.mu.Lock()
+	workers := append([]*worker(nil), g.workers...)
+	g.mu.Unlock()
+
+	for i := len(workers) - 1; i >= 0; i-- {
+		g.mu.Lock()
+		workers[i].halt = true
+		workers[i].cancel()
+		g.mu.Unlock()
+		<-workers[i].exited
+	}
+}
+
+// run launches start with a child context and relaunches it on each
+// graceful.Restart until the graceful context is cancelled
+func (g *graceful) run(w *worker, start func(ctx context.Context, first bool)) {
+
+	defer g.wgShutdown.Done()
+	defer func() {
+		g.mu.Lock()
+		w.done = true
+		g.mu.Unlock()
+		close(w.exited)
+	}()
+
+	// a panic initiates an orderly shutdown rather than crashing
+	defer func() {
+		if err := recover(); err != nil {
+			log.Printf("%s: panic %v\n%s", w.name, err, debug.Stack())
+			g.dump(err)
+			g.exit.CompareAndSwap(0, int32(exitCode[ExitPanic]))
+			g.cancel()
+			g.release(w)
+			g.booted(w)
 		}
+	}()
 
+	g.mu.Lock()
+	ctx := w.ctx
+	g.mu.Unlock()
+
+	for first := true; ; first = false {
+
+		start(ctx, first)
+
+		select {
+		case <-g.ctx.Done():
+			<-ctx.Done() // cancelled with the graceful context or in turn
+			return
+		case <-w.restart: // cancelled by Restart
+			ctx = g.child(w)
+		}
 	}
 }
+
+// Restart cancels the child context of the named managed process and
+// relaunches its Start method once it returns, without a process restart;
+// the name is the lowercase struct type name or the graceful.ManagerNamed
+// name and false is returned when no such managed process is running
+//
+//	grace.Restart("loader") // reload the data file
+func (g *graceful) Restart(name string) bool {
+
+	if g.ctx.Err() != nil {
+		return false
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var ok bool
+	for _, w := range g.workers {
+		if w.name == name && w.cancel != nil {
+			select {
+			case w.restart <- struct{}{}:
+			default: // pending
+			}
+			w.cancel()
+			ok = true
+		}
+	}
+
+	return ok
+}
+
+// Retry calls fn up to attempts times with an exponential backoff between
+// attempts (backoff, 2*backoff, 4*backoff...) for use with flaky bootstrap
+// steps; aborts promptly on context cancel and returns the last error
+//
+//	err := grace.Retry(5, time.Second, func(ctx context.Context) error { return db.PingContext(ctx) })
+func (g *graceful) Retry(attempts int, backoff time.Duration, fn func(ctx context.Context) error) error {
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = fn(g.ctx); err == nil {
+			return nil
+		}
+		if i+1 == attempts {
+			break
+		}
+
+		fire, stop := g.timer(backoff << i)
+		select {
+		case <-g.ctx.Done():
+			stop()
+			return g.ctx.Err()
+		case <-fire:
+		}
+	}
+
+	return err
+}
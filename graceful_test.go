@@ -0,0 +1,89 @@
+package env
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// panicker panics in its bootstrap before calling wg.Done
+type panicker struct{}
+
+func (panicker) Start(ctx context.Context, wg *sync.WaitGroup) { panic("bootstrap") }
+
+func TestGracefulPanic(t *testing.T) {
+
+	SetExitCode(ExitPanic, 30) // distinct from ExitDependency
+	defer SetExitCode(ExitPanic, 1)
+
+	exit := make(chan int, 1)
+	g := NewGraceful().Signals().Silent().Hooks(func(code int) { exit <- code }, func(time.Duration) {})
+	g.Manager(&panicker{})
+
+	select {
+	case code := <-exit:
+		if code != 30 {
+			t.Fatalf("exit code %d", code)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("shutdown hung")
+	}
+	if g.ctx.Err() == nil {
+		t.Fatal("context not cancelled")
+	}
+}
+
+// service completes its bootstrap and runs until cancelled
+type service struct{ stopped chan struct{} }
+
+func (s *service) Start(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Done()
+	<-ctx.Done()
+	close(s.stopped)
+}
+
+// stuck completes its bootstrap and ignores the cancel
+type stuck struct{ release chan struct{} }
+
+func (s *stuck) Start(ctx context.Context, wg *sync.WaitGroup) { wg.Done(); <-s.release }
+
+func TestGracefulLifecycle(t *testing.T) {
+
+	exit := make(chan int, 1)
+	fire := make(chan time.Time)
+	g := NewGraceful().Signals().Silent().SetShutdownTimeout(time.Hour).
+		Hooks(func(code int) { exit <- code }, func(time.Duration) {}).
+		Clock(func(time.Duration) (<-chan time.Time, func() bool) { return fire, func() bool { return true } })
+
+	s, x := &service{stopped: make(chan struct{})}, &stuck{release: make(chan struct{})}
+	defer close(x.release)
+	g.Manager(s, x)
+
+	// init -> ready
+	if !g.DoneTimeout(time.Hour) || !g.Ready() {
+		t.Fatal("not ready")
+	}
+
+	// ready -> shutdown; the stuck process holds the shutdown until the
+	// timeout fires on demand rather than after an hour
+	g.Signal(os.Interrupt)
+	<-s.stopped
+	select {
+	case <-exit:
+		t.Fatal("exit before the shutdown timeout")
+	case fire <- time.Now():
+	case <-time.After(time.Second * 5):
+		t.Fatal("shutdown timeout not armed")
+	}
+
+	select {
+	case code := <-exit:
+		if code != 0 || g.Ready() {
+			t.Fatal(code, g.Ready())
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("shutdown hung")
+	}
+}
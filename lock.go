@@ -1,11 +1,14 @@
 package env
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -25,7 +28,8 @@ import (
 type Lock string
 
 // Exist reports the {file}.lock state as a boolean and
-// expires the lock when past the ttl; default 1hr
+// expires the lock when past the ttl; default 1hr, or
+// when the pid written in the lock is no longer running
 func (lock *Lock) Exist(ttl *time.Duration) bool {
 
 	if ttl == nil || *ttl == 0 {
@@ -38,8 +42,10 @@ func (lock *Lock) Exist(ttl *time.Duration) bool {
 		path = "/tmp"
 	}
 
-	path = filepath.Join(path, filepath.Base(os.Args[0])+".lock")
-	*lock = Lock(path)
+	if filepath.Ext(path) != ".lock" { // resolved by a prior call
+		path = filepath.Join(path, filepath.Base(os.Args[0])+".lock")
+		*lock = Lock(path)
+	}
 
 	if _, err := os.Stat(filepath.Dir(path)); errors.Is(err, fs.ErrNotExist) {
 		os.MkdirAll(filepath.Dir(path), 0755)
@@ -51,20 +57,95 @@ func (lock *Lock) Exist(ttl *time.Duration) bool {
 		return !lock.Unlock()
 	}
 
+	// stale lock; the owner on this host crashed without an Unlock,
+	// the ttl expires the lock of another host on shared storage
+	if host, pid := lock.Owner(); pid > 0 && (len(host) == 0 || host == hostname()) && !alive(pid) {
+		return !lock.Unlock()
+	}
+
 	return !errors.Is(err, fs.ErrNotExist)
 }
 
-// Lock creates a {file}.lock and writes the current pid
+// Lock creates a {file}.lock and writes the current hostname:pid; the
+// create is exclusive so false is reported when the lock is already held,
+// eg. another process acquired it between Exist and Lock
 func (lock Lock) Lock() bool {
 
-	f, err := os.Create(string(lock))
+	f, err := os.OpenFile(string(lock), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
 	if err == nil {
-		fmt.Fprint(f, os.Getpid())
+		fmt.Fprintf(f, "%s:%d", hostname(), os.Getpid())
 		f.Close()
 	}
 
 	return err == nil
 }
 
+// Owner reports the hostname and pid written in the {file}.lock; the
+// pid is 0 when there is no lock and the host is empty for a pid only lock
+func (lock Lock) Owner() (host string, pid int) {
+
+	b, err := os.ReadFile(string(lock))
+	if err != nil {
+		return "", 0
+	}
+
+	s := strings.TrimSpace(string(b))
+	if n := strings.LastIndex(s, ":"); n >= 0 {
+		host, s = s[:n], s[n+1:]
+	}
+	pid, _ = strconv.Atoi(s)
+
+	return host, pid
+}
+
+// hostname of this host; empty when unknown
+func hostname() string {
+	host, _ := os.Hostname()
+	return host
+}
+
+// LockWait polls until the {file}.lock is acquired or the ctx is cancelled,
+// applying the same stale lock rules as Exist; default poll 1s
+//
+//	if !lock.LockWait(ctx, time.Second*5) {
+//		return // cancelled
+//	}
+//	defer lock.Unlock()
+func (lock *Lock) LockWait(ctx context.Context, poll time.Duration) bool {
+
+	if poll == 0 {
+		poll = time.Second // default
+	}
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		if !lock.Exist(nil) && lock.Lock() {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// Acquire is LockWait bounded by timeout rather than a context; reports
+// false when the timeout passes before the {file}.lock is acquired
+//
+//	if !lock.Acquire(time.Minute, time.Second) {
+//		return // still held
+//	}
+//	defer lock.Unlock()
+func (lock *Lock) Acquire(timeout, poll time.Duration) bool {
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return lock.LockWait(ctx, poll)
+}
+
 // Unlock removes a {file}.lock
 func (lock Lock) Unlock() bool { return os.Remove(string(lock)) == nil }
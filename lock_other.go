@@ -0,0 +1,7 @@
+//go:build !unix
+
+package env
+
+// alive assumes the pid is running; signal 0 does not exist on this
+// platform so only the ttl expires the lock
+func alive(pid int) bool { return true }
@@ -0,0 +1,20 @@
+//go:build unix
+
+package env
+
+import (
+	"os"
+	"syscall"
+)
+
+// alive reports whether the pid is a running process using signal 0
+func alive(pid int) bool {
+
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+
+	return err == nil || err == syscall.EPERM // EPERM exists; another user
+}
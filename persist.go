@@ -2,9 +2,12 @@ package env
 
 import (
 	"encoding/gob"
-	"fmt"
+	"encoding/json"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -47,35 +50,107 @@ func (p *Persist) filename() string {
 // Load persist object from disk or remove when older than stated ttl;
 // ignores auto expiration when ttl is nil or 0
 func (p Persist) Load(persist interface{}, ttl *time.Duration) bool {
+	return load(p.filename(), ttl, false, func(r io.Reader) error { return gob.NewDecoder(r).Decode(persist) })
+}
+
+// LoadKeep is Load without removing the file after the decode so the
+// same state can be reloaded across restarts
+func (p Persist) LoadKeep(persist interface{}, ttl *time.Duration) bool {
+	return load(p.filename(), ttl, true, func(r io.Reader) error { return gob.NewDecoder(r).Decode(persist) })
+}
+
+// Save persist object to disk; accepts anything gob can encode and
+// leaves the prior file intact when the encode fails
+func (p Persist) Save(persist interface{}) bool {
+	return save(p.filename(), func(w io.Writer) error { return gob.NewEncoder(w).Encode(persist) }) == nil
+}
+
+// PersistJSON type is Persist using a readable {name}.json file
+type PersistJSON string
+
+// filename verifies location and extension
+func (p *PersistJSON) filename() string {
+
+	if !strings.HasSuffix(string(*p), ".json") {
+		*p += PersistJSON(".json")
+	}
+
+	return string(*p)
+}
+
+// Load persist object from disk or remove when older than stated ttl;
+// ignores auto expiration when ttl is nil or 0
+func (p PersistJSON) Load(persist interface{}, ttl *time.Duration) bool {
+	return load(p.filename(), ttl, false, func(r io.Reader) error { return json.NewDecoder(r).Decode(persist) })
+}
+
+// LoadKeep is Load without removing the file after the decode so the
+// same state can be reloaded across restarts
+func (p PersistJSON) LoadKeep(persist interface{}, ttl *time.Duration) bool {
+	return load(p.filename(), ttl, true, func(r io.Reader) error { return json.NewDecoder(r).Decode(persist) })
+}
+
+// Save persist object to disk as indented json; leaves the prior file
+// intact when the encode fails
+func (p PersistJSON) Save(persist interface{}) bool {
+	return save(p.filename(), func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(persist)
+	}) == nil
+}
+
+// load decodes the path and removes it unless keep; the path is removed
+// without a decode when older than ttl
+func load(path string, ttl *time.Duration, keep bool, decode func(r io.Reader) error) bool {
 
 	if ttl != nil && *ttl > 0 {
-		info, err := os.Stat(p.filename())
+		info, err := os.Stat(path)
 		if os.IsNotExist(err) || info.ModTime().Before(time.Now().Add(-(*ttl))) {
-			os.Remove(string(p))
+			os.Remove(path)
 			return true
 		}
 	}
 
-	f, err := os.Open(p.filename())
+	f, err := os.Open(path)
 	if err == nil {
-		err = gob.NewDecoder(f).Decode(persist)
+		err = decode(f)
 		f.Close()
 	}
 
-	return err == nil && os.Remove(string(p)) == nil
+	return err == nil && (keep || os.Remove(path) == nil)
 }
 
-// Save persist object to disk; accepts anything
-func (p Persist) Save(persist interface{}) bool {
+// save encodes to a temp file renamed over the path so a crash or failed
+// encode never leaves a truncated file and the prior file stays intact; the
+// mode of the prior file is kept (default: 0644)
+func save(path string, encode func(w io.Writer) error) error {
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
 
-	f, err := os.Create(p.filename())
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm() // keep the mode of the prior file
+	}
+
+	err = encode(f)
 	if err == nil {
-		gob.NewEncoder(f).Encode(persist)
-		f.Close()
+		err = f.Chmod(mode)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
 	}
-	fmt.Println(err)
 
-	return err == nil
+	return err
 }
 
 // Map of items with ttl
@@ -114,3 +189,58 @@ func (m *Map) Next(age time.Duration) func() (key string, more bool) {
 		return "", false
 	}
 }
+
+// SyncMap is a Map that is safe for concurrent Add and Next drains
+type SyncMap struct {
+	mu sync.Mutex
+	m  Map
+}
+
+// NewSyncMap from m; nil starts empty
+func NewSyncMap(m Map) *SyncMap {
+	if m == nil {
+		m = make(Map)
+	}
+	return &SyncMap{m: m}
+}
+
+// Add entry
+func (s *SyncMap) Add(k string) {
+	s.mu.Lock()
+	s.m.Add(k)
+	s.mu.Unlock()
+}
+
+// Next returns a function return the key; removes key when used
+// or when older than age, when age is non-zero; each call locks so
+// concurrent drains never return the same key
+func (s *SyncMap) Next(age time.Duration) func() (key string, more bool) {
+
+	s.mu.Lock()
+	next := s.m.Next(age)
+	s.mu.Unlock()
+
+	if next == nil {
+		return nil
+	}
+
+	return func() (string, bool) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return next()
+	}
+}
+
+// Map returns a copy of the entries; eg. for Persist.Save
+func (s *SyncMap) Map() Map {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := make(Map, len(s.m))
+	for k, v := range s.m {
+		m[k] = v
+	}
+
+	return m
+}
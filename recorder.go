@@ -0,0 +1,93 @@
+package env
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+/*
+
+	grace := env.NewGraceful().Recorder(100)
+	...
+	// on an unexpected shutdown the last 100 log lines and the
+	// cause are written to /tmp/{name}.crash
+
+*/
+
+// ring is a bounded log writer that retains the most recent lines
+type ring struct {
+	mu   sync.Mutex
+	line [][]byte
+	next int
+	full bool
+}
+
+// Write retains a copy of the log line; oldest lines are overwritten
+func (r *ring) Write(b []byte) (int, error) {
+
+	r.mu.Lock()
+	r.line[r.next] = append(r.line[r.next][:0], b...)
+	r.next++
+	if r.next == len(r.line) {
+		r.next, r.full = 0, true
+	}
+	r.mu.Unlock()
+
+	return len(b), nil
+}
+
+// dump writes the retained lines to w in the order received
+func (r *ring) dump(w io.Writer) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.full {
+		for i := r.next; i < len(r.line); i++ {
+			w.Write(r.line[i])
+		}
+	}
+	for i := 0; i < r.next; i++ {
+		w.Write(r.line[i])
+	}
+}
+
+// Recorder installs a flight recorder that retains the last n log lines and
+// dumps them with the shutdown cause to a file on an unexpected shutdown;
+// path elements are resolved by env.Dir (default: /tmp/{name}.crash)
+func (g *graceful) Recorder(n int, path ...string) *graceful {
+
+	if n < 1 {
+		n = 100 // default
+	}
+	if len(path) == 0 {
+		path = []string{os.TempDir(), g.name + ".crash"}
+	}
+
+	g.ring = &ring{line: make([][]byte, n)}
+	g.crash = Dir(path...)
+	log.SetOutput(io.MultiWriter(log.Writer(), g.ring))
+
+	return g
+}
+
+// dump writes the flight recorder and cause to the crash file; no-op when
+// the recorder is not installed
+func (g *graceful) dump(cause interface{}) {
+
+	if g.ring == nil {
+		return
+	}
+
+	f, err := os.Create(g.crash)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(f, "%s: %s\ncause: %v\n\n", g.name, time.Now().Format(time.RFC3339), cause)
+	g.ring.dump(f)
+	f.Close()
+}
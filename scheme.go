@@ -0,0 +1,49 @@
+package env
+
+import (
+	"strings"
+	"sync"
+)
+
+/*
+
+	env.RegisterScheme("vault", func(ref string) (string, error) {
+		return vault.Read(ref) // ref is path/to/secret
+	})
+	...
+	-secret vault://path/to/secret
+
+*/
+
+// scheme resolvers registry
+var scheme = struct {
+	sync.RWMutex
+	m map[string]func(ref string) (string, error)
+}{m: make(map[string]func(ref string) (string, error))}
+
+// RegisterScheme registers a resolver that is called by the parser for any
+// value in the {scheme}://{ref} form to fetch the real value
+func RegisterScheme(name string, resolver func(ref string) (string, error)) {
+	scheme.Lock()
+	scheme.m[name] = resolver
+	scheme.Unlock()
+}
+
+// resolve returns the value from a registered scheme resolver; otherwise
+// the value is returned as is
+func resolve(s string) (string, error) {
+
+	n := strings.Index(s, "://")
+	if n < 1 {
+		return s, nil
+	}
+
+	scheme.RLock()
+	resolver, ok := scheme.m[s[:n]]
+	scheme.RUnlock()
+	if !ok {
+		return s, nil
+	}
+
+	return resolver(s[n+3:])
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package env
+
+import "os"
+
+// user defined signals do not exist on this platform; graceful.OnSignal
+// ignores the nil signal
+var (
+	SigUSR1 os.Signal
+	SigUSR2 os.Signal
+)
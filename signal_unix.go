@@ -0,0 +1,14 @@
+//go:build unix
+
+package env
+
+import (
+	"os"
+	"syscall"
+)
+
+// user defined signals for use with graceful.OnSignal
+var (
+	SigUSR1 os.Signal = syscall.SIGUSR1
+	SigUSR2 os.Signal = syscall.SIGUSR2
+)
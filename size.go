@@ -0,0 +1,68 @@
+package env
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sizeUnit multipliers; decimal and binary forms
+var sizeUnit = map[string]int64{
+	"": 1, "b": 1,
+	"k": 1e3, "kb": 1e3, "kib": 1 << 10,
+	"m": 1e6, "mb": 1e6, "mib": 1 << 20,
+	"g": 1e9, "gb": 1e9, "gib": 1 << 30,
+	"t": 1e12, "tb": 1e12, "tib": 1 << 40,
+}
+
+// rateUnit time divisors
+var rateUnit = map[string]time.Duration{
+	"s": time.Second, "sec": time.Second,
+	"m": time.Minute, "min": time.Minute,
+	"h": time.Hour, "hr": time.Hour,
+}
+
+// parseSize converts a humanized size into bytes; eg. 512, 10KB, 1.5GiB
+func parseSize(s string) (int64, error) {
+
+	s = strings.TrimSpace(s)
+	n := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if n < 0 {
+		n = len(s)
+	}
+
+	unit, ok := sizeUnit[strings.ToLower(strings.TrimSpace(s[n:]))]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit %q", s[n:])
+	}
+
+	f, err := strconv.ParseFloat(s[:n], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	return int64(f * float64(unit)), nil
+}
+
+// parseRate converts a humanized {size}/{unit} rate into bytes per second;
+// eg. 10MB/s, 500KiB/min, 1GB/h and a bare size is bytes per second
+func parseRate(s string) (int64, error) {
+
+	size, per := s, "s"
+	if n := strings.LastIndex(s, "/"); n >= 0 {
+		size, per = s[:n], strings.ToLower(strings.TrimSpace(s[n+1:]))
+	}
+
+	d, ok := rateUnit[per]
+	if !ok {
+		return 0, fmt.Errorf("unknown rate unit %q", per)
+	}
+
+	n, err := parseSize(size)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(float64(n) / d.Seconds()), nil
+}
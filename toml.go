@@ -0,0 +1,122 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+
+	[database]
+	host = "db.local"     # database.host
+	port = 5432           # database.port
+	hosts = ["a", "b"]    # database.hosts=a,b
+
+*/
+
+// tomlRead loads a toml file into m as k:v sets where table sections use
+// dotted keys and arrays are joined with commas; this covers the tables,
+// basic/literal strings and (multi-line) arrays used by conf files
+func tomlRead(r io.Reader, m map[string]string) error {
+
+	var table, key, value string
+	var line int
+	var err error
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+
+		line++
+		text := strings.TrimSpace(scanner.Text())
+
+		// continuation of a multi-line array
+		if len(key) > 0 {
+			value += " " + tomlComment(text)
+			if strings.Count(value, "[") > strings.Count(value, "]") {
+				continue
+			}
+			m[key] = tomlArray(value)
+			key = ""
+			continue
+		}
+
+		if len(text) == 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") {
+			table = strings.Trim(tomlComment(text), "[] ") + "."
+			if table == "." {
+				table = ""
+			}
+			continue
+		}
+
+		n := strings.Index(text, "=")
+		if n < 1 {
+			if err == nil {
+				err = fmt.Errorf("line %d: malformed %q", line, text)
+			}
+			continue
+		}
+		k := table + strings.Trim(strings.TrimSpace(text[:n]), `"'`)
+		v := tomlComment(text[n+1:])
+
+		if strings.HasPrefix(v, "[") {
+			if strings.Count(v, "[") > strings.Count(v, "]") {
+				key, value = k, v
+				continue
+			}
+			m[k] = tomlArray(v)
+			continue
+		}
+
+		m[k] = yamlScalar(v)
+	}
+
+	switch {
+	case err != nil:
+	case len(key) > 0:
+		err = fmt.Errorf("unterminated (%s) array", key)
+	default:
+		err = scanner.Err()
+	}
+
+	return err
+}
+
+// tomlComment removes the trailing comment outside of quotes
+func tomlComment(s string) string {
+
+	var quote rune
+	for i, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return strings.TrimSpace(s[:i])
+		}
+	}
+
+	return strings.TrimSpace(s)
+}
+
+// tomlArray joins the array items with commas
+func tomlArray(s string) string {
+
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+
+	var item []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); len(v) > 0 {
+			item = append(item, yamlScalar(v))
+		}
+	}
+
+	return strings.Join(item, ",")
+}
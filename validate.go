@@ -0,0 +1,182 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// bounds checks a numeric field against the range:"min-max" tag or the
+// min:"n" and max:"n" tags; an open bound is not checked
+//
+//	Port int `default:"8080" range:"1-65535"`
+//	Workers int `min:"1" max:"100"`
+func bounds(v reflect.Value, sf reflect.StructField) error {
+
+	var name = strings.ToLower(sf.Name)
+	lo := sf.Tag.Get("min")
+	hi := sf.Tag.Get("max")
+	if r, ok := sf.Tag.Lookup("range"); ok {
+		n := strings.Index(r, "..")
+		switch {
+		case n > 0:
+			lo, hi = r[:n], r[n+2:]
+		case strings.Contains(r[1:], "-"):
+			n = strings.Index(r[1:], "-") + 1
+			lo, hi = r[:n], r[n+1:]
+		default:
+			return fmt.Errorf("%s: malformed range %q", name, r)
+		}
+	}
+
+	return inRange(v, name, lo, hi)
+}
+
+// inRange checks a numeric value against the lo and hi bounds; an empty
+// bound is open and a non-numeric value is not checked
+func inRange(v reflect.Value, name, lo, hi string) error {
+
+	if len(lo) == 0 && len(hi) == 0 {
+		return nil
+	}
+
+	var n float64
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		n = v.Float()
+	default:
+		return nil // not numeric
+	}
+
+	if len(lo) > 0 {
+		min, err := strconv.ParseFloat(lo, 64)
+		if err != nil {
+			return fmt.Errorf("%s: malformed min %q", name, lo)
+		}
+		if n < min {
+			return fmt.Errorf("%s: value out of range [%s..%s]", name, lo, hi)
+		}
+	}
+	if len(hi) > 0 {
+		max, err := strconv.ParseFloat(hi, 64)
+		if err != nil {
+			return fmt.Errorf("%s: malformed max %q", name, hi)
+		}
+		if n > max {
+			return fmt.Errorf("%s: value out of range [%s..%s]", name, lo, hi)
+		}
+	}
+
+	return nil
+}
+
+// oneof checks the field value against the space separated set of accepted
+// values in the oneof tag; ci for a case-insensitive comparison
+//
+//	Action string `env:"a,ci" default:"pull" oneof:"pull process expire export"`
+func oneof(v reflect.Value, sf reflect.StructField, ci bool) error {
+
+	tag, ok := sf.Tag.Lookup("oneof")
+	if !ok {
+		return nil
+	}
+
+	return inSet(v, strings.ToLower(sf.Name), strings.Fields(tag), ci)
+}
+
+// inSet checks the value is one of the accepted values
+func inSet(v reflect.Value, name string, accept []string, ci bool) error {
+
+	value := fmt.Sprint(v.Interface())
+	for i := range accept {
+		if value == accept[i] || ci && strings.EqualFold(value, accept[i]) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s: invalid value %q; accepts [%s]",
+		name, value, strings.Join(accept, "|"))
+}
+
+// valid checks the field value against the constraints of the valid tag;
+// min=n, max=n, oneof=a|b|c and match={regexp} which, when present, must be
+// last as the expression may contain commas; a malformed spec is reported
+// even when the value is unset so it is detected at startup
+//
+//	Workers int `valid:"min=1,max=10"`
+//	Mode string `valid:"oneof=a|b|c"`
+//	Name string `valid:"match=^[a-z][a-z0-9-]*$"`
+func valid(v reflect.Value, sf reflect.StructField, ci, status bool) error {
+
+	tag, ok := sf.Tag.Lookup("valid")
+	if !ok {
+		return nil
+	}
+
+	var name = strings.ToLower(sf.Name)
+	var lo, hi string
+	var accept []string
+	var match *regexp.Regexp
+
+	for len(tag) > 0 {
+		var rule string
+		if strings.HasPrefix(tag, "match=") {
+			rule, tag = tag, ""
+		} else if n := strings.Index(tag, ","); n >= 0 {
+			rule, tag = tag[:n], tag[n+1:]
+		} else {
+			rule, tag = tag, ""
+		}
+
+		kv := strings.SplitN(rule, "=", 2)
+		if len(kv) != 2 || len(kv[1]) == 0 {
+			return fmt.Errorf("%s: malformed valid rule %q", name, rule)
+		}
+
+		switch kv[0] {
+		case "min":
+			lo = kv[1]
+		case "max":
+			hi = kv[1]
+		case "oneof":
+			accept = strings.Split(kv[1], "|")
+		case "match":
+			var err error
+			if match, err = regexp.Compile(kv[1]); err != nil {
+				return fmt.Errorf("%s: malformed valid match; %s", name, err)
+			}
+		default:
+			return fmt.Errorf("%s: unknown valid rule %q", name, kv[0])
+		}
+	}
+
+	for _, bound := range []string{lo, hi} {
+		if _, err := strconv.ParseFloat(bound, 64); len(bound) > 0 && err != nil {
+			return fmt.Errorf("%s: malformed valid bound %q", name, bound)
+		}
+	}
+
+	if !status {
+		return nil // nothing to check
+	}
+
+	if err := inRange(v, name, lo, hi); err != nil {
+		return err
+	}
+	if len(accept) > 0 {
+		if err := inSet(v, name, accept, ci); err != nil {
+			return err
+		}
+	}
+	if match != nil && !match.MatchString(fmt.Sprint(v.Interface())) {
+		return fmt.Errorf("%s: value %q does not match %s", name, fmt.Sprint(v.Interface()), match)
+	}
+
+	return nil
+}
@@ -0,0 +1,132 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+/*
+
+	server:
+	  host: example.com   # server.host
+	  port: 8080          # server.port
+	hosts:                # hosts=a.com,b.com
+	  - a.com
+	  - b.com
+	tags: [x, y]          # tags=x,y
+
+*/
+
+// yamlRead loads a yaml file into m as k:v sets where nested maps use dotted
+// keys and list items are joined with commas; this covers the block
+// mapping, block list and flow list subset used by conf files, anchors and
+// multi-line scalars are not supported
+func yamlRead(r io.Reader, m map[string]string) error {
+
+	type level struct {
+		indent int
+		key    string
+	}
+
+	var stack []level
+	var line int
+	var err error
+	prefix := func(key ...string) string {
+		var k []string
+		for i := range stack {
+			k = append(k, stack[i].key)
+		}
+		return strings.Join(append(k, key...), ".")
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+
+		line++
+		raw := strings.TrimRight(scanner.Text(), " \t")
+		text := strings.TrimSpace(raw)
+		if len(text) == 0 || strings.HasPrefix(text, "#") || text == "---" {
+			continue
+		}
+		indent := len(raw) - len(strings.TrimLeft(raw, " "))
+
+		// list item of the last open key
+		if text == "-" || strings.HasPrefix(text, "- ") {
+			for len(stack) > 0 && stack[len(stack)-1].indent > indent {
+				stack = stack[:len(stack)-1]
+			}
+			if len(stack) == 0 {
+				if err == nil {
+					err = fmt.Errorf("line %d: list item without key", line)
+				}
+				continue
+			}
+			key := prefix()
+			item := yamlScalar(text[1:])
+			if len(m[key]) > 0 {
+				item = m[key] + "," + item
+			}
+			m[key] = item
+			continue
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+
+		n := strings.Index(text, ":")
+		if n < 1 {
+			if err == nil {
+				err = fmt.Errorf("line %d: malformed %q", line, text)
+			}
+			continue
+		}
+		key, value := strings.TrimSpace(text[:n]), strings.TrimSpace(text[n+1:])
+		if len(value) == 0 {
+			stack = append(stack, level{indent, key})
+			continue
+		}
+
+		if strings.HasPrefix(value, "[") {
+			value = strings.Trim(value[:strings.LastIndex(value+"]", "]")], "[]")
+			var item []string
+			for _, v := range strings.Split(value, ",") {
+				item = append(item, yamlScalar(v))
+			}
+			m[prefix(key)] = strings.Join(item, ",")
+			continue
+		}
+
+		m[prefix(key)] = yamlScalar(value)
+	}
+
+	if err == nil {
+		err = scanner.Err()
+	}
+
+	return err
+}
+
+// yamlScalar removes quotes or a trailing comment from the scalar value
+func yamlScalar(s string) string {
+
+	s = strings.TrimSpace(s)
+	switch {
+	case len(s) > 1 && s[0] == '"':
+		if v, err := strconv.Unquote(s[:strings.LastIndex(s, `"`)+1]); err == nil {
+			return v
+		}
+	case len(s) > 1 && s[0] == '\'':
+		if n := strings.LastIndex(s, "'"); n > 0 {
+			return strings.ReplaceAll(s[1:n], "''", "'")
+		}
+	}
+	if n := strings.Index(s, " #"); n >= 0 {
+		s = strings.TrimSpace(s[:n])
+	}
+
+	return s
+}